@@ -0,0 +1,852 @@
+//
+//Copyright (C) 2022-2024 ApeCloud Co., Ltd
+//
+//This file is part of KubeBlocks project
+//
+//This program is free software: you can redistribute it and/or modify
+//it under the terms of the GNU Affero General Public License as published by
+//the Free Software Foundation, either version 3 of the License, or
+//(at your option) any later version.
+//
+//This program is distributed in the hope that it will be useful
+//but WITHOUT ANY WARRANTY; without even the implied warranty of
+//MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+//GNU Affero General Public License for more details.
+//
+//You should have received a copy of the GNU Affero General Public License
+//along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.2
+// source: mongodb_plugin.proto
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MongoDBPlugin_ForceReconfig_FullMethodName                  = "/mongodb.plugin.v1.MongoDBPlugin/ForceReconfig"
+	MongoDBPlugin_CheckLiveness_FullMethodName                  = "/mongodb.plugin.v1.MongoDBPlugin/CheckLiveness"
+	MongoDBPlugin_ListAccounts_FullMethodName                   = "/mongodb.plugin.v1.MongoDBPlugin/ListAccounts"
+	MongoDBPlugin_DescribeAccount_FullMethodName                = "/mongodb.plugin.v1.MongoDBPlugin/DescribeAccount"
+	MongoDBPlugin_RotateRootPassword_FullMethodName             = "/mongodb.plugin.v1.MongoDBPlugin/RotateRootPassword"
+	MongoDBPlugin_ReconcileRoles_FullMethodName                 = "/mongodb.plugin.v1.MongoDBPlugin/ReconcileRoles"
+	MongoDBPlugin_GetClientStats_FullMethodName                 = "/mongodb.plugin.v1.MongoDBPlugin/GetClientStats"
+	MongoDBPlugin_RemoveShard_FullMethodName                    = "/mongodb.plugin.v1.MongoDBPlugin/RemoveShard"
+	MongoDBPlugin_GetBalancerStatus_FullMethodName              = "/mongodb.plugin.v1.MongoDBPlugin/GetBalancerStatus"
+	MongoDBPlugin_StartBalancer_FullMethodName                  = "/mongodb.plugin.v1.MongoDBPlugin/StartBalancer"
+	MongoDBPlugin_StopBalancer_FullMethodName                   = "/mongodb.plugin.v1.MongoDBPlugin/StopBalancer"
+	MongoDBPlugin_SetBalancerWindow_FullMethodName              = "/mongodb.plugin.v1.MongoDBPlugin/SetBalancerWindow"
+	MongoDBPlugin_GetVersions_FullMethodName                    = "/mongodb.plugin.v1.MongoDBPlugin/GetVersions"
+	MongoDBPlugin_CheckUpgrade_FullMethodName                   = "/mongodb.plugin.v1.MongoDBPlugin/CheckUpgrade"
+	MongoDBPlugin_SetFeatureCompatibilityVersion_FullMethodName = "/mongodb.plugin.v1.MongoDBPlugin/SetFeatureCompatibilityVersion"
+	MongoDBPlugin_RollingRestart_FullMethodName                 = "/mongodb.plugin.v1.MongoDBPlugin/RollingRestart"
+	MongoDBPlugin_ResyncMember_FullMethodName                   = "/mongodb.plugin.v1.MongoDBPlugin/ResyncMember"
+	MongoDBPlugin_GetReplicationHealth_FullMethodName           = "/mongodb.plugin.v1.MongoDBPlugin/GetReplicationHealth"
+)
+
+// MongoDBPluginClient is the client API for MongoDBPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MongoDBPluginClient interface {
+	// ForceReconfig rebuilds the replica set from the surviving members after
+	// the loss of a majority. It MUST only be used for disaster recovery and
+	// fails unless HA is disabled.
+	ForceReconfig(ctx context.Context, in *ForceReconfigRequest, opts ...grpc.CallOption) (*ForceReconfigResponse, error)
+	// CheckLiveness tells whether mongod is not listening, is listening but
+	// does not answer isMaster, or answers but is stuck behind a lock.
+	CheckLiveness(ctx context.Context, in *CheckLivenessRequest, opts ...grpc.CallOption) (*CheckLivenessResponse, error)
+	// ListAccounts returns the users of all databases.
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	// DescribeAccount returns a user and the effective privileges of the roles
+	// it is granted.
+	DescribeAccount(ctx context.Context, in *DescribeAccountRequest, opts ...grpc.CallOption) (*DescribeAccountResponse, error)
+	// RotateRootPassword changes the root password. It is called on every
+	// member, the first call changes it on the primary and later calls only
+	// switch the member to the new password. The previous password keeps
+	// working for the member's clients during a grace period.
+	RotateRootPassword(ctx context.Context, in *RotateRootPasswordRequest, opts ...grpc.CallOption) (*RotateRootPasswordResponse, error)
+	// ReconcileRoles converges the custom roles to the role templates and
+	// reports the drift of each role.
+	ReconcileRoles(ctx context.Context, in *ReconcileRolesRequest, opts ...grpc.CallOption) (*ReconcileRolesResponse, error)
+	// GetClientStats returns the connection pool statistics of the clients
+	// the plugin shares between its calls.
+	GetClientStats(ctx context.Context, in *GetClientStatsRequest, opts ...grpc.CallOption) (*GetClientStatsResponse, error)
+	// RemoveShard drains the shard of the component out of the sharded
+	// cluster before the component is scaled in. The first call starts the
+	// draining, which the plugin then drives to completion, and every call
+	// reports its progress.
+	RemoveShard(ctx context.Context, in *RemoveShardRequest, opts ...grpc.CallOption) (*RemoveShardResponse, error)
+	// GetBalancerStatus returns the state of the balancer of the sharded
+	// cluster and its window.
+	GetBalancerStatus(ctx context.Context, in *GetBalancerStatusRequest, opts ...grpc.CallOption) (*GetBalancerStatusResponse, error)
+	// StartBalancer enables the balancer, after a backup or a maintenance.
+	StartBalancer(ctx context.Context, in *StartBalancerRequest, opts ...grpc.CallOption) (*StartBalancerResponse, error)
+	// StopBalancer disables the balancer and returns once no chunk migration
+	// is in progress, so that a backup sees a consistent chunk distribution.
+	StopBalancer(ctx context.Context, in *StopBalancerRequest, opts ...grpc.CallOption) (*StopBalancerResponse, error)
+	// SetBalancerWindow restricts the balancer to a daily window, or lifts the
+	// restriction.
+	SetBalancerWindow(ctx context.Context, in *SetBalancerWindowRequest, opts ...grpc.CallOption) (*SetBalancerWindowResponse, error)
+	// GetVersions returns the binary version and featureCompatibilityVersion
+	// of every member.
+	GetVersions(ctx context.Context, in *GetVersionsRequest, opts ...grpc.CallOption) (*GetVersionsResponse, error)
+	// CheckUpgrade tells whether the binaries can be upgraded to the target
+	// version, which needs the featureCompatibilityVersion to match the
+	// release every member runs for a new major or minor release.
+	CheckUpgrade(ctx context.Context, in *CheckUpgradeRequest, opts ...grpc.CallOption) (*CheckUpgradeResponse, error)
+	// SetFeatureCompatibilityVersion raises the featureCompatibilityVersion
+	// once every member runs the new release, or lowers it to roll an upgrade
+	// back.
+	SetFeatureCompatibilityVersion(ctx context.Context, in *SetFeatureCompatibilityVersionRequest, opts ...grpc.CallOption) (*SetFeatureCompatibilityVersionResponse, error)
+	// RollingRestart plans the restart of the members, secondaries first and
+	// the primary last, and reports which member to restart next. Each member
+	// must be back as a secondary that caught up before the next one is
+	// restarted, and the primary is stepped down before its restart. The
+	// rolling restart is forgotten once reported completed.
+	RollingRestart(ctx context.Context, in *RollingRestartRequest, opts ...grpc.CallOption) (*RollingRestartResponse, error)
+	// ResyncMember rebuilds the data of the member the plugin runs beside,
+	// once it fell off the oplog window: it is removed from the replica set,
+	// its data directory is wiped and it is added back to run an initial sync.
+	// The primary is never resynced, nor a member whose removal would lose the
+	// majority of the voting members.
+	ResyncMember(ctx context.Context, in *ResyncMemberRequest, opts ...grpc.CallOption) (*ResyncMemberResponse, error)
+	// GetReplicationHealth returns the replication lag of every member and the
+	// oplog window of the primary, with a warning for each secondary whose lag
+	// approaches the window.
+	GetReplicationHealth(ctx context.Context, in *GetReplicationHealthRequest, opts ...grpc.CallOption) (*GetReplicationHealthResponse, error)
+}
+
+type mongoDBPluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMongoDBPluginClient(cc grpc.ClientConnInterface) MongoDBPluginClient {
+	return &mongoDBPluginClient{cc}
+}
+
+func (c *mongoDBPluginClient) ForceReconfig(ctx context.Context, in *ForceReconfigRequest, opts ...grpc.CallOption) (*ForceReconfigResponse, error) {
+	out := new(ForceReconfigResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_ForceReconfig_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) CheckLiveness(ctx context.Context, in *CheckLivenessRequest, opts ...grpc.CallOption) (*CheckLivenessResponse, error) {
+	out := new(CheckLivenessResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_CheckLiveness_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_ListAccounts_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) DescribeAccount(ctx context.Context, in *DescribeAccountRequest, opts ...grpc.CallOption) (*DescribeAccountResponse, error) {
+	out := new(DescribeAccountResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_DescribeAccount_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) RotateRootPassword(ctx context.Context, in *RotateRootPasswordRequest, opts ...grpc.CallOption) (*RotateRootPasswordResponse, error) {
+	out := new(RotateRootPasswordResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_RotateRootPassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) ReconcileRoles(ctx context.Context, in *ReconcileRolesRequest, opts ...grpc.CallOption) (*ReconcileRolesResponse, error) {
+	out := new(ReconcileRolesResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_ReconcileRoles_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) GetClientStats(ctx context.Context, in *GetClientStatsRequest, opts ...grpc.CallOption) (*GetClientStatsResponse, error) {
+	out := new(GetClientStatsResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_GetClientStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) RemoveShard(ctx context.Context, in *RemoveShardRequest, opts ...grpc.CallOption) (*RemoveShardResponse, error) {
+	out := new(RemoveShardResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_RemoveShard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) GetBalancerStatus(ctx context.Context, in *GetBalancerStatusRequest, opts ...grpc.CallOption) (*GetBalancerStatusResponse, error) {
+	out := new(GetBalancerStatusResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_GetBalancerStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) StartBalancer(ctx context.Context, in *StartBalancerRequest, opts ...grpc.CallOption) (*StartBalancerResponse, error) {
+	out := new(StartBalancerResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_StartBalancer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) StopBalancer(ctx context.Context, in *StopBalancerRequest, opts ...grpc.CallOption) (*StopBalancerResponse, error) {
+	out := new(StopBalancerResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_StopBalancer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) SetBalancerWindow(ctx context.Context, in *SetBalancerWindowRequest, opts ...grpc.CallOption) (*SetBalancerWindowResponse, error) {
+	out := new(SetBalancerWindowResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_SetBalancerWindow_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) GetVersions(ctx context.Context, in *GetVersionsRequest, opts ...grpc.CallOption) (*GetVersionsResponse, error) {
+	out := new(GetVersionsResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_GetVersions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) CheckUpgrade(ctx context.Context, in *CheckUpgradeRequest, opts ...grpc.CallOption) (*CheckUpgradeResponse, error) {
+	out := new(CheckUpgradeResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_CheckUpgrade_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) SetFeatureCompatibilityVersion(ctx context.Context, in *SetFeatureCompatibilityVersionRequest, opts ...grpc.CallOption) (*SetFeatureCompatibilityVersionResponse, error) {
+	out := new(SetFeatureCompatibilityVersionResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_SetFeatureCompatibilityVersion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) RollingRestart(ctx context.Context, in *RollingRestartRequest, opts ...grpc.CallOption) (*RollingRestartResponse, error) {
+	out := new(RollingRestartResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_RollingRestart_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) ResyncMember(ctx context.Context, in *ResyncMemberRequest, opts ...grpc.CallOption) (*ResyncMemberResponse, error) {
+	out := new(ResyncMemberResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_ResyncMember_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mongoDBPluginClient) GetReplicationHealth(ctx context.Context, in *GetReplicationHealthRequest, opts ...grpc.CallOption) (*GetReplicationHealthResponse, error) {
+	out := new(GetReplicationHealthResponse)
+	err := c.cc.Invoke(ctx, MongoDBPlugin_GetReplicationHealth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MongoDBPluginServer is the server API for MongoDBPlugin service.
+// All implementations must embed UnimplementedMongoDBPluginServer
+// for forward compatibility
+type MongoDBPluginServer interface {
+	// ForceReconfig rebuilds the replica set from the surviving members after
+	// the loss of a majority. It MUST only be used for disaster recovery and
+	// fails unless HA is disabled.
+	ForceReconfig(context.Context, *ForceReconfigRequest) (*ForceReconfigResponse, error)
+	// CheckLiveness tells whether mongod is not listening, is listening but
+	// does not answer isMaster, or answers but is stuck behind a lock.
+	CheckLiveness(context.Context, *CheckLivenessRequest) (*CheckLivenessResponse, error)
+	// ListAccounts returns the users of all databases.
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	// DescribeAccount returns a user and the effective privileges of the roles
+	// it is granted.
+	DescribeAccount(context.Context, *DescribeAccountRequest) (*DescribeAccountResponse, error)
+	// RotateRootPassword changes the root password. It is called on every
+	// member, the first call changes it on the primary and later calls only
+	// switch the member to the new password. The previous password keeps
+	// working for the member's clients during a grace period.
+	RotateRootPassword(context.Context, *RotateRootPasswordRequest) (*RotateRootPasswordResponse, error)
+	// ReconcileRoles converges the custom roles to the role templates and
+	// reports the drift of each role.
+	ReconcileRoles(context.Context, *ReconcileRolesRequest) (*ReconcileRolesResponse, error)
+	// GetClientStats returns the connection pool statistics of the clients
+	// the plugin shares between its calls.
+	GetClientStats(context.Context, *GetClientStatsRequest) (*GetClientStatsResponse, error)
+	// RemoveShard drains the shard of the component out of the sharded
+	// cluster before the component is scaled in. The first call starts the
+	// draining, which the plugin then drives to completion, and every call
+	// reports its progress.
+	RemoveShard(context.Context, *RemoveShardRequest) (*RemoveShardResponse, error)
+	// GetBalancerStatus returns the state of the balancer of the sharded
+	// cluster and its window.
+	GetBalancerStatus(context.Context, *GetBalancerStatusRequest) (*GetBalancerStatusResponse, error)
+	// StartBalancer enables the balancer, after a backup or a maintenance.
+	StartBalancer(context.Context, *StartBalancerRequest) (*StartBalancerResponse, error)
+	// StopBalancer disables the balancer and returns once no chunk migration
+	// is in progress, so that a backup sees a consistent chunk distribution.
+	StopBalancer(context.Context, *StopBalancerRequest) (*StopBalancerResponse, error)
+	// SetBalancerWindow restricts the balancer to a daily window, or lifts the
+	// restriction.
+	SetBalancerWindow(context.Context, *SetBalancerWindowRequest) (*SetBalancerWindowResponse, error)
+	// GetVersions returns the binary version and featureCompatibilityVersion
+	// of every member.
+	GetVersions(context.Context, *GetVersionsRequest) (*GetVersionsResponse, error)
+	// CheckUpgrade tells whether the binaries can be upgraded to the target
+	// version, which needs the featureCompatibilityVersion to match the
+	// release every member runs for a new major or minor release.
+	CheckUpgrade(context.Context, *CheckUpgradeRequest) (*CheckUpgradeResponse, error)
+	// SetFeatureCompatibilityVersion raises the featureCompatibilityVersion
+	// once every member runs the new release, or lowers it to roll an upgrade
+	// back.
+	SetFeatureCompatibilityVersion(context.Context, *SetFeatureCompatibilityVersionRequest) (*SetFeatureCompatibilityVersionResponse, error)
+	// RollingRestart plans the restart of the members, secondaries first and
+	// the primary last, and reports which member to restart next. Each member
+	// must be back as a secondary that caught up before the next one is
+	// restarted, and the primary is stepped down before its restart. The
+	// rolling restart is forgotten once reported completed.
+	RollingRestart(context.Context, *RollingRestartRequest) (*RollingRestartResponse, error)
+	// ResyncMember rebuilds the data of the member the plugin runs beside,
+	// once it fell off the oplog window: it is removed from the replica set,
+	// its data directory is wiped and it is added back to run an initial sync.
+	// The primary is never resynced, nor a member whose removal would lose the
+	// majority of the voting members.
+	ResyncMember(context.Context, *ResyncMemberRequest) (*ResyncMemberResponse, error)
+	// GetReplicationHealth returns the replication lag of every member and the
+	// oplog window of the primary, with a warning for each secondary whose lag
+	// approaches the window.
+	GetReplicationHealth(context.Context, *GetReplicationHealthRequest) (*GetReplicationHealthResponse, error)
+	mustEmbedUnimplementedMongoDBPluginServer()
+}
+
+// UnimplementedMongoDBPluginServer must be embedded to have forward compatible implementations.
+type UnimplementedMongoDBPluginServer struct {
+}
+
+func (UnimplementedMongoDBPluginServer) ForceReconfig(context.Context, *ForceReconfigRequest) (*ForceReconfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceReconfig not implemented")
+}
+func (UnimplementedMongoDBPluginServer) CheckLiveness(context.Context, *CheckLivenessRequest) (*CheckLivenessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckLiveness not implemented")
+}
+func (UnimplementedMongoDBPluginServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
+}
+func (UnimplementedMongoDBPluginServer) DescribeAccount(context.Context, *DescribeAccountRequest) (*DescribeAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribeAccount not implemented")
+}
+func (UnimplementedMongoDBPluginServer) RotateRootPassword(context.Context, *RotateRootPasswordRequest) (*RotateRootPasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateRootPassword not implemented")
+}
+func (UnimplementedMongoDBPluginServer) ReconcileRoles(context.Context, *ReconcileRolesRequest) (*ReconcileRolesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconcileRoles not implemented")
+}
+func (UnimplementedMongoDBPluginServer) GetClientStats(context.Context, *GetClientStatsRequest) (*GetClientStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClientStats not implemented")
+}
+func (UnimplementedMongoDBPluginServer) RemoveShard(context.Context, *RemoveShardRequest) (*RemoveShardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveShard not implemented")
+}
+func (UnimplementedMongoDBPluginServer) GetBalancerStatus(context.Context, *GetBalancerStatusRequest) (*GetBalancerStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalancerStatus not implemented")
+}
+func (UnimplementedMongoDBPluginServer) StartBalancer(context.Context, *StartBalancerRequest) (*StartBalancerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartBalancer not implemented")
+}
+func (UnimplementedMongoDBPluginServer) StopBalancer(context.Context, *StopBalancerRequest) (*StopBalancerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopBalancer not implemented")
+}
+func (UnimplementedMongoDBPluginServer) SetBalancerWindow(context.Context, *SetBalancerWindowRequest) (*SetBalancerWindowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetBalancerWindow not implemented")
+}
+func (UnimplementedMongoDBPluginServer) GetVersions(context.Context, *GetVersionsRequest) (*GetVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersions not implemented")
+}
+func (UnimplementedMongoDBPluginServer) CheckUpgrade(context.Context, *CheckUpgradeRequest) (*CheckUpgradeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckUpgrade not implemented")
+}
+func (UnimplementedMongoDBPluginServer) SetFeatureCompatibilityVersion(context.Context, *SetFeatureCompatibilityVersionRequest) (*SetFeatureCompatibilityVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFeatureCompatibilityVersion not implemented")
+}
+func (UnimplementedMongoDBPluginServer) RollingRestart(context.Context, *RollingRestartRequest) (*RollingRestartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollingRestart not implemented")
+}
+func (UnimplementedMongoDBPluginServer) ResyncMember(context.Context, *ResyncMemberRequest) (*ResyncMemberResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResyncMember not implemented")
+}
+func (UnimplementedMongoDBPluginServer) GetReplicationHealth(context.Context, *GetReplicationHealthRequest) (*GetReplicationHealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicationHealth not implemented")
+}
+func (UnimplementedMongoDBPluginServer) mustEmbedUnimplementedMongoDBPluginServer() {}
+
+// UnsafeMongoDBPluginServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MongoDBPluginServer will
+// result in compilation errors.
+type UnsafeMongoDBPluginServer interface {
+	mustEmbedUnimplementedMongoDBPluginServer()
+}
+
+func RegisterMongoDBPluginServer(s grpc.ServiceRegistrar, srv MongoDBPluginServer) {
+	s.RegisterService(&MongoDBPlugin_ServiceDesc, srv)
+}
+
+func _MongoDBPlugin_ForceReconfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceReconfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).ForceReconfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_ForceReconfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).ForceReconfig(ctx, req.(*ForceReconfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_CheckLiveness_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckLivenessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).CheckLiveness(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_CheckLiveness_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).CheckLiveness(ctx, req.(*CheckLivenessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_ListAccounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_DescribeAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).DescribeAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_DescribeAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).DescribeAccount(ctx, req.(*DescribeAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_RotateRootPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateRootPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).RotateRootPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_RotateRootPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).RotateRootPassword(ctx, req.(*RotateRootPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_ReconcileRoles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileRolesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).ReconcileRoles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_ReconcileRoles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).ReconcileRoles(ctx, req.(*ReconcileRolesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_GetClientStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClientStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).GetClientStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_GetClientStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).GetClientStats(ctx, req.(*GetClientStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_RemoveShard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveShardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).RemoveShard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_RemoveShard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).RemoveShard(ctx, req.(*RemoveShardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_GetBalancerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalancerStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).GetBalancerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_GetBalancerStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).GetBalancerStatus(ctx, req.(*GetBalancerStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_StartBalancer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartBalancerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).StartBalancer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_StartBalancer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).StartBalancer(ctx, req.(*StartBalancerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_StopBalancer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopBalancerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).StopBalancer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_StopBalancer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).StopBalancer(ctx, req.(*StopBalancerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_SetBalancerWindow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetBalancerWindowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).SetBalancerWindow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_SetBalancerWindow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).SetBalancerWindow(ctx, req.(*SetBalancerWindowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_GetVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).GetVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_GetVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).GetVersions(ctx, req.(*GetVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_CheckUpgrade_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckUpgradeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).CheckUpgrade(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_CheckUpgrade_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).CheckUpgrade(ctx, req.(*CheckUpgradeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_SetFeatureCompatibilityVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFeatureCompatibilityVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).SetFeatureCompatibilityVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_SetFeatureCompatibilityVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).SetFeatureCompatibilityVersion(ctx, req.(*SetFeatureCompatibilityVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_RollingRestart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollingRestartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).RollingRestart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_RollingRestart_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).RollingRestart(ctx, req.(*RollingRestartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_ResyncMember_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResyncMemberRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).ResyncMember(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_ResyncMember_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).ResyncMember(ctx, req.(*ResyncMemberRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MongoDBPlugin_GetReplicationHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReplicationHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MongoDBPluginServer).GetReplicationHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MongoDBPlugin_GetReplicationHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MongoDBPluginServer).GetReplicationHealth(ctx, req.(*GetReplicationHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MongoDBPlugin_ServiceDesc is the grpc.ServiceDesc for MongoDBPlugin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MongoDBPlugin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mongodb.plugin.v1.MongoDBPlugin",
+	HandlerType: (*MongoDBPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ForceReconfig",
+			Handler:    _MongoDBPlugin_ForceReconfig_Handler,
+		},
+		{
+			MethodName: "CheckLiveness",
+			Handler:    _MongoDBPlugin_CheckLiveness_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _MongoDBPlugin_ListAccounts_Handler,
+		},
+		{
+			MethodName: "DescribeAccount",
+			Handler:    _MongoDBPlugin_DescribeAccount_Handler,
+		},
+		{
+			MethodName: "RotateRootPassword",
+			Handler:    _MongoDBPlugin_RotateRootPassword_Handler,
+		},
+		{
+			MethodName: "ReconcileRoles",
+			Handler:    _MongoDBPlugin_ReconcileRoles_Handler,
+		},
+		{
+			MethodName: "GetClientStats",
+			Handler:    _MongoDBPlugin_GetClientStats_Handler,
+		},
+		{
+			MethodName: "RemoveShard",
+			Handler:    _MongoDBPlugin_RemoveShard_Handler,
+		},
+		{
+			MethodName: "GetBalancerStatus",
+			Handler:    _MongoDBPlugin_GetBalancerStatus_Handler,
+		},
+		{
+			MethodName: "StartBalancer",
+			Handler:    _MongoDBPlugin_StartBalancer_Handler,
+		},
+		{
+			MethodName: "StopBalancer",
+			Handler:    _MongoDBPlugin_StopBalancer_Handler,
+		},
+		{
+			MethodName: "SetBalancerWindow",
+			Handler:    _MongoDBPlugin_SetBalancerWindow_Handler,
+		},
+		{
+			MethodName: "GetVersions",
+			Handler:    _MongoDBPlugin_GetVersions_Handler,
+		},
+		{
+			MethodName: "CheckUpgrade",
+			Handler:    _MongoDBPlugin_CheckUpgrade_Handler,
+		},
+		{
+			MethodName: "SetFeatureCompatibilityVersion",
+			Handler:    _MongoDBPlugin_SetFeatureCompatibilityVersion_Handler,
+		},
+		{
+			MethodName: "RollingRestart",
+			Handler:    _MongoDBPlugin_RollingRestart_Handler,
+		},
+		{
+			MethodName: "ResyncMember",
+			Handler:    _MongoDBPlugin_ResyncMember_Handler,
+		},
+		{
+			MethodName: "GetReplicationHealth",
+			Handler:    _MongoDBPlugin_GetReplicationHealth_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "mongodb_plugin.proto",
+}
@@ -65,6 +65,22 @@ const (
 	Candidate = "candidate"
 )
 
+// MongoDBArbiterKey is the pod label or annotation that, set to "true", makes
+// the member join the replica set as an arbiter.
+const MongoDBArbiterKey = "mongodb.kubeblocks.io/arbiter"
+
+// MongoDBHiddenKey is the pod label or annotation that, set to "true", keeps
+// the member hidden from clients and out of elections.
+const MongoDBHiddenKey = "mongodb.kubeblocks.io/hidden"
+
+// MongoDBDelaySecsKey is the pod label or annotation with the number of
+// seconds the member lags behind the primary on purpose.
+const MongoDBDelaySecsKey = "mongodb.kubeblocks.io/secondary-delay-secs"
+
+// MongoDBComponentRoleKey is the pod label or annotation with the role of the
+// component in a sharded cluster: configsvr, shardsvr or mongos.
+const MongoDBComponentRoleKey = "mongodb.kubeblocks.io/component-role"
+
 // switchover constants
 
 // username and password are keys in created secrets for others to refer to.
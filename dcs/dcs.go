@@ -45,7 +45,8 @@ type DCS interface {
 
 	// manual switchover
 	GetSwitchover() (*Switchover, error)
-	CreateSwitchover(string, string) error
+	CreateSwitchover(leader, candidate string, scheduledAt int64) error
+	UpdateSwitchover(*Switchover) error
 	DeleteSwitchover() error
 
 	// cluster scope leader Lease
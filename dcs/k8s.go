@@ -253,6 +253,16 @@ func (store *KubernetesStore) GetMembers() ([]Member, error) {
 		if pod.Spec.HostNetwork {
 			member.UseIP = true
 		}
+		member.IsArbiter = isArbiterPod(&pod)
+		member.Hidden = getPodMarker(&pod, constant.MongoDBHiddenKey) == "true"
+		if delay := getPodMarker(&pod, constant.MongoDBDelaySecsKey); delay != "" {
+			member.DelaySecs, err = strconv.ParseInt(delay, 10, 64)
+			if err != nil || member.DelaySecs < 0 {
+				store.logger.Info("ignore invalid secondary delay", "pod", pod.Name, "delay", delay)
+				member.DelaySecs = 0
+			}
+		}
+		member.ComponentRole = getPodMarker(&pod, constant.MongoDBComponentRoleKey)
 		member.resource = pod.DeepCopy()
 		members = append(members, member)
 	}
@@ -545,6 +555,42 @@ func (store *KubernetesStore) GetHaConfig() (*HaConfig, error) {
 		}
 	}
 
+	var forceReconfigs []ForceReconfigRecord
+	str = annotations["force-reconfigs"]
+	if str != "" {
+		err := json.Unmarshal([]byte(str), &forceReconfigs)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("Get force reconfigs [%s] error", str))
+		}
+	}
+
+	var managedRoles []string
+	str = annotations["managed-roles"]
+	if str != "" {
+		err := json.Unmarshal([]byte(str), &managedRoles)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("Get managed roles [%s] error", str))
+		}
+	}
+
+	var shardRemoval *ShardRemovalRecord
+	str = annotations["shard-removal"]
+	if str != "" {
+		err := json.Unmarshal([]byte(str), &shardRemoval)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("Get shard removal [%s] error", str))
+		}
+	}
+
+	var rollingRestart *RollingRestartRecord
+	str = annotations["rolling-restart"]
+	if str != "" {
+		err := json.Unmarshal([]byte(str), &rollingRestart)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("Get rolling restart [%s] error", str))
+		}
+	}
+
 	return &HaConfig{
 		index:                  configmap.ResourceVersion,
 		ClusterInitializeOwner: annotations["ClusterInitializeOwner"],
@@ -552,6 +598,10 @@ func (store *KubernetesStore) GetHaConfig() (*HaConfig, error) {
 		enable:                 enable,
 		maxLagOnSwitchover:     int64(maxLagOnSwitchover),
 		DeleteMembers:          deleteMembers,
+		ForceReconfigs:         forceReconfigs,
+		ManagedRoles:           managedRoles,
+		ShardRemoval:           shardRemoval,
+		RollingRestart:         rollingRestart,
 		resource:               configmap,
 	}, err
 }
@@ -570,6 +620,36 @@ func (store *KubernetesStore) UpdateHaConfig() error {
 		store.logger.Error(err, fmt.Sprintf("marsha delete members [%v]", haConfig))
 	}
 	annotations["delete-members"] = string(deleteMembers)
+	if len(haConfig.ForceReconfigs) > 0 {
+		forceReconfigs, err := json.Marshal(haConfig.ForceReconfigs)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("marsha force reconfigs [%v]", haConfig))
+		}
+		annotations["force-reconfigs"] = string(forceReconfigs)
+	}
+	if haConfig.ManagedRoles != nil {
+		managedRoles, err := json.Marshal(haConfig.ManagedRoles)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("marsha managed roles [%v]", haConfig))
+		}
+		annotations["managed-roles"] = string(managedRoles)
+	}
+	if haConfig.ShardRemoval != nil {
+		shardRemoval, err := json.Marshal(haConfig.ShardRemoval)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("marsha shard removal [%v]", haConfig))
+		}
+		annotations["shard-removal"] = string(shardRemoval)
+	}
+	if haConfig.RollingRestart != nil {
+		rollingRestart, err := json.Marshal(haConfig.RollingRestart)
+		if err != nil {
+			store.logger.Error(err, fmt.Sprintf("marsha rolling restart [%v]", haConfig))
+		}
+		annotations["rolling-restart"] = string(rollingRestart)
+	} else {
+		delete(annotations, "rolling-restart")
+	}
 	annotations["MaxLagOnSwitchover"] = strconv.Itoa(int(haConfig.maxLagOnSwitchover))
 
 	_, err = store.clientset.CoreV1().ConfigMaps(store.namespace).Update(context.TODO(), configMap, metav1.UpdateOptions{})
@@ -597,10 +677,16 @@ func (store *KubernetesStore) GetSwitchover() (*Switchover, error) {
 	annotations := switchOverConfigMap.Annotations
 	scheduledAt, _ := strconv.Atoi(annotations["scheduled-at"])
 	switchOver := newSwitchover(switchOverConfigMap.ResourceVersion, annotations["leader"], annotations["candidate"], int64(scheduledAt))
+	if status := annotations["status"]; status != "" {
+		switchOver.Status = status
+	}
+	switchOver.Reason = annotations["reason"]
+	switchOver.StartTime, _ = strconv.ParseInt(annotations["started-at"], 10, 64)
+	switchOver.resource = switchOverConfigMap
 	return switchOver, nil
 }
 
-func (store *KubernetesStore) CreateSwitchover(leader, candidate string) error {
+func (store *KubernetesStore) CreateSwitchover(leader, candidate string, scheduledAt int64) error {
 	switchoverName := store.getSwitchoverName()
 	switchover, _ := store.GetSwitchover()
 	if switchover != nil {
@@ -614,9 +700,13 @@ func (store *KubernetesStore) CreateSwitchover(leader, candidate string) error {
 			Annotations: map[string]string{
 				"leader":    leader,
 				"candidate": candidate,
+				"status":    SwitchoverPending,
 			},
 		},
 	}
+	if scheduledAt > 0 {
+		swConfigMap.Annotations["scheduled-at"] = strconv.FormatInt(scheduledAt, 10)
+	}
 
 	err := store.createConfigMap(swConfigMap)
 	if err != nil {
@@ -626,6 +716,33 @@ func (store *KubernetesStore) CreateSwitchover(leader, candidate string) error {
 	return nil
 }
 
+// UpdateSwitchover records the status, reason and start time of the
+// switchover. The update fails if the record changed since it was read, so
+// that only one caller can claim a pending switchover.
+func (store *KubernetesStore) UpdateSwitchover(switchover *Switchover) error {
+	configMap, ok := switchover.resource.(*corev1.ConfigMap)
+	if !ok || configMap == nil {
+		return errors.New("No switchover configmap")
+	}
+
+	if configMap.Annotations == nil {
+		configMap.Annotations = map[string]string{}
+	}
+	configMap.Annotations["status"] = switchover.Status
+	configMap.Annotations["reason"] = switchover.Reason
+	if switchover.StartTime > 0 {
+		configMap.Annotations["started-at"] = strconv.FormatInt(switchover.StartTime, 10)
+	}
+	cm, err := store.clientset.CoreV1().ConfigMaps(store.namespace).Update(store.ctx, configMap, metav1.UpdateOptions{})
+	if err != nil {
+		store.logger.Error(err, "Update switchover configmap failed")
+		return err
+	}
+	switchover.Index = cm.ResourceVersion
+	switchover.resource = cm
+	return nil
+}
+
 func (store *KubernetesStore) DeleteSwitchover() error {
 	switchoverName := store.getSwitchoverName()
 	err := store.clientset.CoreV1().ConfigMaps(store.namespace).Delete(store.ctx, switchoverName, metav1.DeleteOptions{})
@@ -679,6 +796,21 @@ func (store *KubernetesStore) AddCurrentMember() error {
 	return nil
 }
 
+// isArbiterPod returns true if the pod is marked as an arbiter by label or
+// annotation.
+func isArbiterPod(pod *corev1.Pod) bool {
+	return getPodMarker(pod, constant.MongoDBArbiterKey) == "true"
+}
+
+// getPodMarker returns the value of the pod label with the given key, or of
+// the annotation if there is no such label.
+func getPodMarker(pod *corev1.Pod, key string) string {
+	if value, ok := pod.Labels[key]; ok {
+		return value
+	}
+	return pod.Annotations[key]
+}
+
 // TODO: Use the database instance's character type to determine its port number more precisely
 func getDBPort(pod *corev1.Pod) string {
 	if len(pod.Spec.Containers) == 0 {
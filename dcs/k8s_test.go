@@ -298,6 +298,51 @@ func TestGetMembers(t *testing.T) {
 	assert.NotNil(t, members)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(members))
+	assert.False(t, members[0].IsArbiter)
+
+	t.Run("arbiter pod", func(t *testing.T) {
+		pods := mockPods(3, Namespace, ClusterName)
+		pods.Items[1].Annotations = map[string]string{constant.MongoDBArbiterKey: "true"}
+		pods.Items[2].Labels[constant.MongoDBArbiterKey] = "true"
+		store.clientset = kubefakeclient.NewSimpleClientset(pods)
+
+		members, err := store.GetMembers()
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(members))
+		assert.False(t, members[0].IsArbiter)
+		assert.True(t, members[1].IsArbiter)
+		assert.True(t, members[2].IsArbiter)
+	})
+
+	t.Run("hidden and delayed pods", func(t *testing.T) {
+		pods := mockPods(3, Namespace, ClusterName)
+		pods.Items[1].Labels[constant.MongoDBHiddenKey] = "true"
+		pods.Items[2].Annotations = map[string]string{
+			constant.MongoDBHiddenKey:    "true",
+			constant.MongoDBDelaySecsKey: "3600",
+		}
+		store.clientset = kubefakeclient.NewSimpleClientset(pods)
+
+		members, err := store.GetMembers()
+		assert.Nil(t, err)
+		assert.False(t, members[0].Hidden)
+		assert.Equal(t, int64(0), members[0].DelaySecs)
+		assert.True(t, members[1].Hidden)
+		assert.Equal(t, int64(0), members[1].DelaySecs)
+		assert.True(t, members[2].Hidden)
+		assert.Equal(t, int64(3600), members[2].DelaySecs)
+	})
+
+	t.Run("component role", func(t *testing.T) {
+		pods := mockPods(2, Namespace, ClusterName)
+		pods.Items[1].Labels[constant.MongoDBComponentRoleKey] = "configsvr"
+		store.clientset = kubefakeclient.NewSimpleClientset(pods)
+
+		members, err := store.GetMembers()
+		assert.Nil(t, err)
+		assert.Equal(t, "", members[0].ComponentRole)
+		assert.Equal(t, "configsvr", members[1].ComponentRole)
+	})
 }
 
 func TestGetLeaderConfigMap(t *testing.T) {
@@ -620,6 +665,70 @@ func TestHaConfig(t *testing.T) {
 		assert.Equal(t, 10, haConfig.ttl)
 		assert.Equal(t, int64(100), haConfig.maxLagOnSwitchover)
 	})
+
+	t.Run("record force reconfigs", func(t *testing.T) {
+		haConfig := &HaConfig{resource: configMap}
+		for i := 0; i < maxForceReconfigRecords+2; i++ {
+			haConfig.AddForceReconfig(ForceReconfigRecord{Time: int64(i), Member: "pod-0", Members: []string{"pod-0"}})
+		}
+		store.cluster = &Cluster{HaConfig: haConfig}
+		store.clientset = kubefakeclient.NewSimpleClientset(configMap)
+
+		err = store.UpdateHaConfig()
+		assert.Nil(t, err)
+		haConfig, err := store.GetHaConfig()
+		assert.Nil(t, err)
+		assert.Len(t, haConfig.ForceReconfigs, maxForceReconfigRecords)
+		assert.Equal(t, int64(2), haConfig.ForceReconfigs[0].Time)
+		assert.Equal(t, []string{"pod-0"}, haConfig.ForceReconfigs[0].Members)
+	})
+
+	t.Run("record managed roles", func(t *testing.T) {
+		haConfig := &HaConfig{resource: configMap, ManagedRoles: []string{"app-read", "app-write"}}
+		store.cluster = &Cluster{HaConfig: haConfig}
+		store.clientset = kubefakeclient.NewSimpleClientset(configMap)
+
+		err = store.UpdateHaConfig()
+		assert.Nil(t, err)
+		haConfig, err := store.GetHaConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app-read", "app-write"}, haConfig.ManagedRoles)
+	})
+
+	t.Run("record shard removal", func(t *testing.T) {
+		removal := &ShardRemovalRecord{Shard: "mongo-shard-0", State: "ongoing", StartTime: 10, UpdateTime: 20}
+		haConfig := &HaConfig{resource: configMap, ShardRemoval: removal}
+		store.cluster = &Cluster{HaConfig: haConfig}
+		store.clientset = kubefakeclient.NewSimpleClientset(configMap)
+
+		err = store.UpdateHaConfig()
+		assert.Nil(t, err)
+		haConfig, err := store.GetHaConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, removal, haConfig.ShardRemoval)
+	})
+
+	t.Run("record rolling restart", func(t *testing.T) {
+		restart := &RollingRestartRecord{Members: []string{"pod-1", "pod-2", "pod-0"}, StartTime: 30}
+		haConfig := &HaConfig{resource: configMap, RollingRestart: restart}
+		store.cluster = &Cluster{HaConfig: haConfig}
+		store.clientset = kubefakeclient.NewSimpleClientset(configMap)
+
+		err = store.UpdateHaConfig()
+		assert.Nil(t, err)
+		haConfig, err := store.GetHaConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, restart, haConfig.RollingRestart)
+
+		// a completed rolling restart is removed
+		haConfig.RollingRestart = nil
+		store.cluster = &Cluster{HaConfig: haConfig}
+		err = store.UpdateHaConfig()
+		assert.Nil(t, err)
+		haConfig, err = store.GetHaConfig()
+		assert.Nil(t, err)
+		assert.Nil(t, haConfig.RollingRestart)
+	})
 }
 
 func TestSwitchoverConfig(t *testing.T) {
@@ -634,7 +743,7 @@ func TestSwitchoverConfig(t *testing.T) {
 	t.Run("there is another switchover unfinished", func(t *testing.T) {
 		store.clientset = kubefakeclient.NewSimpleClientset(configMap)
 
-		err := store.CreateSwitchover("pod-0", "pod-1")
+		err := store.CreateSwitchover("pod-0", "pod-1", 0)
 		assert.NotNil(t, err)
 		assert.ErrorContains(t, err, "there is another switchover fake-cluster-component-name-switchover unfinished")
 		switchover, err := store.GetSwitchover()
@@ -650,7 +759,7 @@ func TestSwitchoverConfig(t *testing.T) {
 			Resource: mockCluster(ClusterName, Namespace),
 		}
 
-		err := store.CreateSwitchover("pod-0", "pod-1")
+		err := store.CreateSwitchover("pod-0", "pod-1", 0)
 		assert.Nil(t, err)
 		switchover, err := store.GetSwitchover()
 		assert.Nil(t, err)
@@ -658,6 +767,36 @@ func TestSwitchoverConfig(t *testing.T) {
 		assert.Equal(t, "pod-1", switchover.Candidate)
 	})
 
+	t.Run("update switchover status", func(t *testing.T) {
+		store.clientset = kubefakeclient.NewSimpleClientset()
+
+		err := store.CreateSwitchover("pod-0", "pod-1", 0)
+		assert.Nil(t, err)
+		switchover, err := store.GetSwitchover()
+		assert.Nil(t, err)
+		assert.Equal(t, SwitchoverPending, switchover.Status)
+		assert.Equal(t, int64(0), switchover.StartTime)
+
+		switchover.Status = SwitchoverRunning
+		switchover.StartTime = 200
+		err = store.UpdateSwitchover(switchover)
+		assert.Nil(t, err)
+		switchover, err = store.GetSwitchover()
+		assert.Nil(t, err)
+		assert.Equal(t, SwitchoverRunning, switchover.Status)
+		assert.Equal(t, int64(200), switchover.StartTime)
+
+		switchover.Status = SwitchoverFailed
+		switchover.Reason = "timed out"
+		err = store.UpdateSwitchover(switchover)
+		assert.Nil(t, err)
+		switchover, err = store.GetSwitchover()
+		assert.Nil(t, err)
+		assert.Equal(t, SwitchoverFailed, switchover.Status)
+		assert.Equal(t, "timed out", switchover.Reason)
+		assert.Equal(t, int64(200), switchover.StartTime)
+	})
+
 	t.Run("delete switchover failed", func(t *testing.T) {
 		store.clientset = kubefakeclient.NewSimpleClientset()
 
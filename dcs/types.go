@@ -135,6 +135,39 @@ type MemberToDelete struct {
 	IsFinished bool
 }
 
+// ForceReconfigRecord is the audit entry of a forced replica set
+// reconfiguration.
+type ForceReconfigRecord struct {
+	Time          int64
+	Member        string
+	Members       []string
+	RemovedHosts  []string
+	Reason        string
+	ConfigVersion int64
+	Error         string `json:",omitempty"`
+}
+
+// ShardRemovalRecord is the state of a shard being drained out of the
+// sharded cluster.
+type ShardRemovalRecord struct {
+	Shard      string
+	State      string
+	StartTime  int64
+	UpdateTime int64
+}
+
+// RollingRestartRecord is a rolling restart in progress: the order in which
+// the members are restarted and when it started, members whose process is
+// younger are restarted.
+type RollingRestartRecord struct {
+	Members   []string
+	StartTime int64
+}
+
+// maxForceReconfigRecords is the number of force reconfig audit entries kept
+// in the HA config.
+const maxForceReconfigRecords = 10
+
 type HaConfig struct {
 	index                  string
 	ClusterInitializeOwner string
@@ -142,7 +175,16 @@ type HaConfig struct {
 	enable                 bool
 	maxLagOnSwitchover     int64
 	DeleteMembers          map[string]MemberToDelete
-	resource               any
+	ForceReconfigs         []ForceReconfigRecord
+	// ManagedRoles are the roles created from the role templates, the ones
+	// removed from the templates are dropped.
+	ManagedRoles []string
+	// ShardRemoval is the removal of the component's shard from the sharded
+	// cluster, kept once completed so that the shard is not added back.
+	ShardRemoval *ShardRemovalRecord
+	// RollingRestart is the rolling restart of the members in progress.
+	RollingRestart *RollingRestartRecord
+	resource       any
 }
 
 func (c *HaConfig) GetTTL() int {
@@ -161,6 +203,15 @@ func (c *HaConfig) GetMaxLagOnSwitchover() int64 {
 	return c.maxLagOnSwitchover
 }
 
+// AddForceReconfig appends the audit entry, dropping the oldest entries beyond
+// maxForceReconfigRecords.
+func (c *HaConfig) AddForceReconfig(record ForceReconfigRecord) {
+	c.ForceReconfigs = append(c.ForceReconfigs, record)
+	if len(c.ForceReconfigs) > maxForceReconfigRecords {
+		c.ForceReconfigs = c.ForceReconfigs[len(c.ForceReconfigs)-maxForceReconfigRecords:]
+	}
+}
+
 func (c *HaConfig) IsDeleting(member *Member) bool {
 	memberToDelete := c.GetMemberToDelete(member)
 	return memberToDelete != nil
@@ -247,6 +298,10 @@ type Member struct {
 	UID           string
 	ComponentName string
 	UseIP         bool
+	IsArbiter     bool
+	Hidden        bool
+	DelaySecs     int64
+	ComponentRole string
 	resource      any
 }
 
@@ -262,11 +317,23 @@ func (m *Member) GetName() string {
 // 	}
 // }
 
+const (
+	SwitchoverPending   = "Pending"
+	SwitchoverRunning   = "Running"
+	SwitchoverSucceeded = "Succeeded"
+	SwitchoverFailed    = "Failed"
+)
+
 type Switchover struct {
 	Index       string
 	Leader      string
 	Candidate   string
 	ScheduledAt int64
+	Status      string
+	Reason      string
+	// StartTime is when the switchover was claimed to run, in unix seconds.
+	StartTime int64
+	resource  any
 }
 
 func newSwitchover(index string, leader string, candidate string, scheduledAt int64) *Switchover {
@@ -275,9 +342,22 @@ func newSwitchover(index string, leader string, candidate string, scheduledAt in
 		Leader:      leader,
 		Candidate:   candidate,
 		ScheduledAt: scheduledAt,
+		Status:      SwitchoverPending,
 	}
 }
 
+// IsDue returns true if the switchover should be executed at the given unix time.
+func (s *Switchover) IsDue(now int64) bool {
+	return s.Status == SwitchoverPending && s.ScheduledAt <= now
+}
+
+// IsStale returns true if the switchover has been running for longer than
+// the timeout in seconds, e.g. because the plugin running it restarted. A
+// running switchover without start time is stale too.
+func (s *Switchover) IsStale(now int64, timeout int64) bool {
+	return s.Status == SwitchoverRunning && now-s.StartTime > timeout
+}
+
 func (s *Switchover) GetLeader() string {
 	return s.Leader
 }
@@ -187,3 +187,19 @@ func TestHAConfig(t *testing.T) {
 		assert.True(t, isDeleted)
 	})
 }
+
+func TestSwitchoverState(t *testing.T) {
+	switchover := newSwitchover("1", "pod-0", "pod-1", 100)
+	assert.False(t, switchover.IsDue(99))
+	assert.True(t, switchover.IsDue(100))
+	assert.False(t, switchover.IsStale(1000, 600))
+
+	switchover.Status = SwitchoverRunning
+	switchover.StartTime = 100
+	assert.False(t, switchover.IsDue(100))
+	assert.False(t, switchover.IsStale(700, 600))
+	assert.True(t, switchover.IsStale(701, 600))
+
+	switchover.StartTime = 0
+	assert.True(t, switchover.IsStale(701, 600))
+}
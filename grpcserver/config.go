@@ -20,14 +20,18 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package grpcserver
 
 import (
+	"time"
+
 	"github.com/spf13/pflag"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 type Config struct {
-	Port       int
-	Address    string
-	APILogging bool
+	Port              int
+	Address           string
+	APILogging        bool
+	ReconcileInterval time.Duration
+	MetricsPort       int
 }
 
 var config Config
@@ -36,4 +40,6 @@ var logger = ctrl.Log.WithName("GRPCServer")
 func init() {
 	pflag.IntVar(&config.Port, "grpc-port", 3701, "The GRPC Server listen port for syncer service.")
 	pflag.StringVar(&config.Address, "grpc-address", "0.0.0.0", "The GRPC Server listen address for syncer service.")
+	pflag.IntVar(&config.MetricsPort, "metrics-port", 3702, "The HTTP listen port for the metrics endpoint, disabled if 0.")
+	pflag.DurationVar(&config.ReconcileInterval, "reconcile-interval", 10*time.Second, "The interval to reconcile pending switchovers.")
 }
@@ -0,0 +1,277 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/apecloud/mongodb_plugin/mongodb"
+)
+
+const metricsNamespace = "mongodb_plugin"
+
+// metricsRegistry holds the metrics of the plugin.
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	grpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "The gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+	grpcRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "How long the gRPC calls took, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(grpcRequests, grpcRequestDuration)
+}
+
+// observeGRPC counts the gRPC calls and their latency.
+func observeGRPC(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	grpcRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+	grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	return resp, err
+}
+
+// serveMetrics serves the registry on /metrics.
+func serveMetrics(address string, port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+	addr := fmt.Sprintf("%s:%d", address, port)
+	logger.Info("Serving metrics", "addr", addr)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	if err := server.ListenAndServe(); err != nil {
+		logger.Error(err, "Metrics server stopped")
+	}
+}
+
+// replicationScrapeTimeout bounds the replica set commands run on a scrape.
+const replicationScrapeTimeout = 5 * time.Second
+
+var (
+	replicationLagSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "replication", "lag_seconds"),
+		"How many seconds the member is behind the primary.",
+		[]string{"member"}, nil)
+	replicationLagOperationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "replication", "lag_operations"),
+		"How many oplog entries of the primary the member has not applied yet, estimated from the average rate of the oplog window.",
+		[]string{"member"}, nil)
+	replicationLagWarningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "replication", "lag_warning"),
+		"1 if the lag of the member approaches the oplog window.",
+		[]string{"member"}, nil)
+	oplogWindowSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "oplog", "window_seconds"),
+		"How many seconds of operations the oplog of the primary holds.",
+		nil, nil)
+	oplogFirstTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "oplog", "first_timestamp_seconds"),
+		"The time of the oldest entry of the oplog of the primary.",
+		nil, nil)
+	oplogLastTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "oplog", "last_timestamp_seconds"),
+		"The time of the newest entry of the oplog of the primary.",
+		nil, nil)
+)
+
+// replicationCollector reports the replication health when scraped. Only
+// the plugin beside the primary reports it, so that the oplog of the primary
+// is not read once per member.
+type replicationCollector struct {
+	plugin *DBPlugin
+}
+
+func newReplicationCollector(plugin *DBPlugin) *replicationCollector {
+	return &replicationCollector{plugin: plugin}
+}
+
+func (c *replicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- replicationLagSecondsDesc
+	ch <- replicationLagOperationsDesc
+	ch <- replicationLagWarningDesc
+	ch <- oplogWindowSecondsDesc
+	ch <- oplogFirstTimestampDesc
+	ch <- oplogLastTimestampDesc
+}
+
+func (c *replicationCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.plugin.dbManager == nil || c.plugin.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), replicationScrapeTimeout)
+	defer cancel()
+	isLeader, err := c.plugin.dbManager.IsLeader(ctx, nil)
+	if err != nil || !isLeader {
+		return
+	}
+	cluster, err := c.plugin.store.GetCluster()
+	if cluster == nil {
+		logger.Info("Collect replication metrics get cluster failed", "error", err)
+		return
+	}
+	health, err := c.plugin.dbManager.GetReplicationHealth(ctx, cluster, false)
+	if err != nil {
+		logger.Info("Collect replication metrics failed", "error", err.Error())
+		return
+	}
+
+	for _, member := range health.Members {
+		ch <- prometheus.MustNewConstMetric(replicationLagSecondsDesc, prometheus.GaugeValue, float64(member.LagSecs), member.Host)
+		ch <- prometheus.MustNewConstMetric(replicationLagOperationsDesc, prometheus.GaugeValue, float64(member.LagOps), member.Host)
+		ch <- prometheus.MustNewConstMetric(replicationLagWarningDesc, prometheus.GaugeValue, boolToFloat(member.Warning != ""), member.Host)
+	}
+	ch <- prometheus.MustNewConstMetric(oplogWindowSecondsDesc, prometheus.GaugeValue, float64(health.OplogWindow.Seconds()))
+	ch <- prometheus.MustNewConstMetric(oplogFirstTimestampDesc, prometheus.GaugeValue, float64(health.OplogWindow.First.T))
+	ch <- prometheus.MustNewConstMetric(oplogLastTimestampDesc, prometheus.GaugeValue, float64(health.OplogWindow.Last.T))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var (
+	memberStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "member", "state"),
+		"The replica set state of the member as seen by the local member, by state name.",
+		[]string{"member", "state"}, nil)
+	memberHealthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "member", "health"),
+		"1 if the member is up as seen by the local member.",
+		[]string{"member"}, nil)
+	replSetTermDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "replset", "term"),
+		"The election term of the replica set.",
+		nil, nil)
+	configVersionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "replset", "config_version"),
+		"The version of the replica set config of the local member.",
+		nil, nil)
+	electionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "elections", "total"),
+		"The elections the local member called, by reason.",
+		[]string{"reason"}, nil)
+	lockedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "locked"),
+		"1 if the plugin locked the database read only.",
+		nil, nil)
+	leaseRenewAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "dcs", "lease_renew_age_seconds"),
+		"How many seconds ago the leader lease in the DCS was renewed.",
+		nil, nil)
+)
+
+// memberCollector reports the replica set as seen by the local member, the
+// lock state and the age of the leader lease when scraped. The lag of the
+// members is reported by the replicationCollector.
+type memberCollector struct {
+	plugin *DBPlugin
+}
+
+func newMemberCollector(plugin *DBPlugin) *memberCollector {
+	return &memberCollector{plugin: plugin}
+}
+
+func (c *memberCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- memberStateDesc
+	ch <- memberHealthDesc
+	ch <- replSetTermDesc
+	ch <- configVersionDesc
+	ch <- electionsDesc
+	ch <- lockedDesc
+	ch <- leaseRenewAgeDesc
+}
+
+func (c *memberCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.plugin.store != nil {
+		leader, err := c.plugin.store.GetLeader()
+		if err == nil && leader != nil && leader.RenewTime > 0 {
+			age := time.Now().Unix() - leader.RenewTime
+			ch <- prometheus.MustNewConstMetric(leaseRenewAgeDesc, prometheus.GaugeValue, float64(age))
+		}
+	}
+
+	if c.plugin.dbManager == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(lockedDesc, prometheus.GaugeValue, boolToFloat(c.plugin.dbManager.IsLocked.Load()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), replicationScrapeTimeout)
+	defer cancel()
+	rsStatus, err := c.plugin.dbManager.GetReplSetStatus(ctx)
+	if err != nil {
+		logger.Info("Collect member metrics failed", "error", err.Error())
+		return
+	}
+	c.collectReplSetStatus(ch, rsStatus)
+
+	electionMetrics, err := c.plugin.dbManager.GetElectionMetrics(ctx)
+	if err != nil {
+		logger.Info("Collect election metrics failed", "error", err.Error())
+		return
+	}
+	if electionMetrics != nil {
+		for reason, counter := range map[string]mongodb.ElectionCounter{
+			"stepUpCmd":        electionMetrics.StepUpCmd,
+			"priorityTakeover": electionMetrics.PriorityTakeover,
+			"catchUpTakeover":  electionMetrics.CatchUpTakeover,
+			"electionTimeout":  electionMetrics.ElectionTimeout,
+			"freezeTimeout":    electionMetrics.FreezeTimeout,
+		} {
+			ch <- prometheus.MustNewConstMetric(electionsDesc, prometheus.CounterValue, float64(counter.Called), reason)
+		}
+	}
+}
+
+func (c *memberCollector) collectReplSetStatus(ch chan<- prometheus.Metric, rsStatus *mongodb.ReplSetStatus) {
+	ch <- prometheus.MustNewConstMetric(replSetTermDesc, prometheus.GaugeValue, float64(rsStatus.Term))
+	if self := rsStatus.GetSelf(); self != nil {
+		ch <- prometheus.MustNewConstMetric(configVersionDesc, prometheus.GaugeValue, float64(self.ConfigVersion))
+	}
+
+	for _, member := range rsStatus.Members {
+		if member == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(memberStateDesc, prometheus.GaugeValue, float64(member.State), member.Name, member.StateStr)
+		ch <- prometheus.MustNewConstMetric(memberHealthDesc, prometheus.GaugeValue, boolToFloat(member.Health == mongodb.MemberHealthUp), member.Name)
+	}
+}
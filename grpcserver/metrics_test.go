@@ -0,0 +1,107 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apecloud/mongodb_plugin/mongodb"
+)
+
+func TestReplicationCollector(t *testing.T) {
+	collector := newReplicationCollector(&DBPlugin{})
+
+	descs := make(chan *prometheus.Desc, 10)
+	collector.Describe(descs)
+	close(descs)
+	assert.Len(t, descs, 6)
+
+	metrics := make(chan prometheus.Metric, 10)
+	collector.Collect(metrics)
+	close(metrics)
+	assert.Len(t, metrics, 0)
+}
+
+func TestObserveGRPC(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Observed"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		if req == nil {
+			return nil, status.Error(codes.Unavailable, "unavailable")
+		}
+		return req, nil
+	}
+
+	_, err := observeGRPC(context.Background(), "req", info, handler)
+	assert.Nil(t, err)
+	_, err = observeGRPC(context.Background(), nil, info, handler)
+	assert.NotNil(t, err)
+
+	families, err := metricsRegistry.Gather()
+	assert.Nil(t, err)
+	counts := map[string]float64{}
+	var observed uint64
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["method"] != info.FullMethod {
+				continue
+			}
+			switch family.GetName() {
+			case "mongodb_plugin_grpc_requests_total":
+				counts[labels["code"]] += metric.GetCounter().GetValue()
+			case "mongodb_plugin_grpc_request_duration_seconds":
+				observed += metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"OK": 1, "Unavailable": 1}, counts)
+	assert.Equal(t, uint64(2), observed)
+}
+
+func TestMemberCollector(t *testing.T) {
+	collector := newMemberCollector(&DBPlugin{})
+
+	metrics := make(chan prometheus.Metric, 10)
+	collector.Collect(metrics)
+	close(metrics)
+	assert.Len(t, metrics, 0)
+
+	rsStatus := &mongodb.ReplSetStatus{
+		Term: 3,
+		Members: []*mongodb.Member{
+			{Name: "10.0.0.1:27017", State: mongodb.MemberStatePrimary, StateStr: "PRIMARY", Health: mongodb.MemberHealthUp, Self: true, ConfigVersion: 5},
+			{Name: "10.0.0.2:27017", State: mongodb.MemberStateSecondary, StateStr: "SECONDARY", Health: mongodb.MemberHealthUp},
+		},
+	}
+	metrics = make(chan prometheus.Metric, 10)
+	collector.collectReplSetStatus(metrics, rsStatus)
+	close(metrics)
+	assert.Len(t, metrics, 6)
+}
@@ -22,18 +22,30 @@ package grpcserver
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 
 	"github.com/apecloud/kubeblocks/pkg/kb_agent/plugin"
+	v1 "github.com/apecloud/mongodb_plugin/api/v1"
 	"github.com/apecloud/mongodb_plugin/constant"
 	"github.com/apecloud/mongodb_plugin/dcs"
 	"github.com/apecloud/mongodb_plugin/mongodb"
 )
 
+// arbiterKey is the join member request metadata key that, set to "true",
+// adds the new member as an arbiter.
+const arbiterKey = "arbiter"
+
+// scheduledAtKey is the switchover request metadata key for the time to run
+// the switchover, either a unix timestamp or an RFC3339 time.
+const scheduledAtKey = "scheduled-at"
+
 type DBPlugin struct {
 	plugin.UnimplementedEnginePluginServer
+	v1.UnimplementedMongoDBPluginServer
 	dbManager *mongodb.Manager
 	store     dcs.DCS
 }
@@ -56,7 +68,7 @@ func (p *DBPlugin) GetPluginInfo(ctx context.Context, in *plugin.GetPluginInfoRe
 }
 
 func (p *DBPlugin) IsEngineReady(ctx context.Context, in *plugin.IsEngineReadyRequest) (*plugin.IsEngineReadyResponse, error) {
-	isReady := p.dbManager.IsDBStartupReady()
+	isReady := p.dbManager.IsDBStartupReady() && p.dbManager.IsResponding()
 
 	resp := &plugin.IsEngineReadyResponse{
 		Ready: isReady,
@@ -84,7 +96,8 @@ func (p *DBPlugin) JoinMember(ctx context.Context, in *plugin.JoinMemberRequest)
 	}
 
 	memberName := in.NewMember
-	err = p.dbManager.JoinMemberToCluster(ctx, cluster, memberName)
+	arbiter := in.Metadata[arbiterKey] == "true"
+	err = p.dbManager.JoinMemberToCluster(ctx, cluster, memberName, arbiter)
 	if err != nil {
 		return nil, err
 	}
@@ -119,26 +132,19 @@ func (p *DBPlugin) ReadWrite(ctx context.Context, in *plugin.ReadWriteRequest) (
 	return &plugin.ReadWriteResponse{}, err
 }
 
-// func (p *DBPlugin) AccountProvision(ctx context.Context, in *plugin.AccountProvisionRequest) (*plugin.AccountProvisionResponse, error) {
-// 	userInfo := models.UserInfo{
-// 		UserName: in.UserName,
-// 		Password: in.Password,
-// 		RoleName: in.Role,
-// 	}
-//
-// 	err := p.dbManager.CreateUser(ctx, userInfo.UserName, userInfo.Password)
-// 	if err != nil {
-// 		return &plugin.AccountProvisionResponse{}, err
-// 	}
-//
-// 	if userInfo.RoleName != "" {
-// 		err := p.dbManager.GrantUserRole(ctx, userInfo.UserName, userInfo.RoleName)
-// 		if err != nil {
-// 			return &plugin.AccountProvisionResponse{}, err
-// 		}
-// 	}
-// 	return &plugin.AccountProvisionResponse{}, err
-// }
+func (p *DBPlugin) AccountProvision(ctx context.Context, in *plugin.AccountProvisionRequest) (*plugin.AccountProvisionResponse, error) {
+	resp := &plugin.AccountProvisionResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	err = p.dbManager.ProvisionAccount(ctx, cluster, in.UserName, in.Password, in.Role)
+	if err != nil {
+		return resp, errors.Wrap(err, "account provision failed")
+	}
+	return resp, nil
+}
 
 func (p *DBPlugin) Switchover(ctx context.Context, in *plugin.SwitchoverRequest) (*plugin.SwitchoverResponse, error) {
 	resp := &plugin.SwitchoverResponse{}
@@ -188,11 +194,557 @@ func (p *DBPlugin) Switchover(ctx context.Context, in *plugin.SwitchoverRequest)
 		return resp, errors.New("candidate is not set and has no other healthy members")
 	}
 
-	err = p.store.CreateSwitchover(primary, candidate)
+	scheduledAt, err := ParseScheduledAt(in.Metadata[scheduledAtKey])
+	if err != nil {
+		return resp, err
+	}
+
+	err = p.store.CreateSwitchover(primary, candidate, scheduledAt)
 	if err != nil {
 		message := fmt.Sprintf("Create switchover failed: %v", err)
 		return resp, errors.New(message)
 	}
 
+	if scheduledAt > time.Now().Unix() {
+		logger.Info("Switchover scheduled", "primary", primary, "candidate", candidate, "scheduledAt", scheduledAt)
+		return resp, nil
+	}
+
+	switchover, err := p.store.GetSwitchover()
+	if switchover == nil {
+		return resp, errors.Wrap(err, "get switchover failed")
+	}
+	err = p.executeSwitchover(ctx, cluster, switchover)
+	if err != nil {
+		return resp, errors.Wrap(err, "switchover failed")
+	}
+
+	return resp, nil
+}
+
+// executeSwitchover claims the pending switchover, runs it, records its
+// outcome on the switchover record and deletes the record afterwards. The
+// RPC and the reconciler may both pick up the same pending record, only the
+// one whose claim succeeds runs and deletes it.
+func (p *DBPlugin) executeSwitchover(ctx context.Context, cluster *dcs.Cluster, switchover *dcs.Switchover) error {
+	switchover.Status = dcs.SwitchoverRunning
+	switchover.StartTime = time.Now().Unix()
+	if err := p.store.UpdateSwitchover(switchover); err != nil {
+		return errors.Wrap(err, "claim switchover failed")
+	}
+	defer func() {
+		if err := p.store.DeleteSwitchover(); err != nil {
+			logger.Info("Delete switchover failed", "error", err.Error())
+		}
+	}()
+
+	err := p.dbManager.Switchover(ctx, cluster, switchover.Leader, switchover.Candidate)
+	if err != nil {
+		switchover.Status = dcs.SwitchoverFailed
+		switchover.Reason = err.Error()
+	} else {
+		switchover.Status = dcs.SwitchoverSucceeded
+		switchover.Reason = ""
+	}
+	if uerr := p.store.UpdateSwitchover(switchover); uerr != nil {
+		logger.Info("Record switchover outcome failed", "status", switchover.Status, "error", uerr.Error())
+	}
+	return err
+}
+
+// ForceReconfig rebuilds the replica set from the surviving members. It only
+// runs while HA is disabled, so that it does not fight the HA controller, and
+// records an audit entry in the HA config whether it succeeds or not.
+func (p *DBPlugin) ForceReconfig(ctx context.Context, in *v1.ForceReconfigRequest) (*v1.ForceReconfigResponse, error) {
+	resp := &v1.ForceReconfigResponse{}
+	if len(in.Members) == 0 {
+		return resp, errors.New("surviving members must be set")
+	}
+
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+	if cluster.HaConfig == nil || cluster.HaConfig.IsEnable() {
+		return resp, errors.New("cluster's ha must be disabled before a force reconfig")
+	}
+
+	rsConfig, removed, err := p.dbManager.ForceReconfig(ctx, cluster, in.Members)
+	record := dcs.ForceReconfigRecord{
+		Time:         time.Now().Unix(),
+		Member:       p.dbManager.CurrentMemberName,
+		Members:      in.Members,
+		RemovedHosts: removed,
+		Reason:       in.Reason,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.ConfigVersion = int64(rsConfig.Version)
+		resp.ConfigVersion = record.ConfigVersion
+		resp.RemovedHosts = removed
+	}
+
+	cluster.HaConfig.AddForceReconfig(record)
+	if uerr := p.store.UpdateHaConfig(); uerr != nil {
+		logger.Info("Record force reconfig failed", "error", uerr.Error())
+		if err == nil {
+			err = errors.Wrap(uerr, "record force reconfig failed")
+		}
+	}
+	if err != nil {
+		return resp, errors.Wrap(err, "force reconfig failed")
+	}
+	return resp, nil
+}
+
+// CheckLiveness reports whether mongod is alive, and why not if it is not.
+func (p *DBPlugin) CheckLiveness(ctx context.Context, in *v1.CheckLivenessRequest) (*v1.CheckLivenessResponse, error) {
+	liveness := p.dbManager.CheckLiveness(ctx)
+	resp := &v1.CheckLivenessResponse{
+		Alive:  liveness.IsAlive(),
+		Status: liveness.Status,
+		Reason: liveness.Reason,
+	}
+	return resp, nil
+}
+
+// ListAccounts returns the users of all databases with their roles and
+// authentication mechanisms.
+func (p *DBPlugin) ListAccounts(ctx context.Context, in *v1.ListAccountsRequest) (*v1.ListAccountsResponse, error) {
+	resp := &v1.ListAccountsResponse{}
+	users, err := p.dbManager.ListAccounts(ctx)
+	if err != nil {
+		return resp, errors.Wrap(err, "list accounts failed")
+	}
+
+	for i := range users {
+		resp.Accounts = append(resp.Accounts, toAccount(&users[i]))
+	}
+	return resp, nil
+}
+
+// DescribeAccount returns a user and the effective privileges of its roles.
+func (p *DBPlugin) DescribeAccount(ctx context.Context, in *v1.DescribeAccountRequest) (*v1.DescribeAccountResponse, error) {
+	resp := &v1.DescribeAccountResponse{}
+	if in.UserName == "" {
+		return resp, errors.New("user name must be set")
+	}
+
+	user, roles, err := p.dbManager.DescribeAccount(ctx, in.UserName, in.Db)
+	if err != nil {
+		return resp, errors.Wrap(err, "describe account failed")
+	}
+
+	resp.Account = toAccount(user)
+	for _, role := range roles {
+		for _, privilege := range role.InheritedPrivileges {
+			resp.Privileges = append(resp.Privileges, toPrivilege(privilege))
+		}
+	}
+	return resp, nil
+}
+
+func toAccount(user *mongodb.User) *v1.Account {
+	account := &v1.Account{
+		UserName:   user.User,
+		Db:         user.DB,
+		Mechanisms: user.Mechanisms,
+	}
+	for _, role := range user.Roles {
+		name, _ := role["role"].(string)
+		db, _ := role["db"].(string)
+		account.Roles = append(account.Roles, &v1.AccountRole{Role: name, Db: db})
+	}
+	return account
+}
+
+func toPrivilege(privilege mongodb.RolePrivilege) *v1.Privilege {
+	resource := &v1.Resource{}
+	resource.Db, _ = privilege.Resource["db"].(string)
+	resource.Collection, _ = privilege.Resource["collection"].(string)
+	resource.Cluster, _ = privilege.Resource["cluster"].(bool)
+	resource.AnyResource, _ = privilege.Resource["anyResource"].(bool)
+	return &v1.Privilege{
+		Resource: resource,
+		Actions:  privilege.Actions,
+	}
+}
+
+func (p *DBPlugin) RotateRootPassword(ctx context.Context, in *v1.RotateRootPasswordRequest) (*v1.RotateRootPasswordResponse, error) {
+	resp := &v1.RotateRootPasswordResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	graceUntil, err := p.dbManager.RotateRootPassword(ctx, cluster, in.Password)
+	if err != nil {
+		return resp, errors.Wrap(err, "rotate root password failed")
+	}
+	if !graceUntil.IsZero() {
+		resp.GracePeriodEnd = graceUntil.Unix()
+	}
+	return resp, nil
+}
+
+// ReconcileRoles converges the custom roles to the role templates, or only
+// reports their drift on a dry run.
+func (p *DBPlugin) ReconcileRoles(ctx context.Context, in *v1.ReconcileRolesRequest) (*v1.ReconcileRolesResponse, error) {
+	resp := &v1.ReconcileRolesResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	drifts, err := p.reconcileRoles(ctx, cluster, in.DryRun)
+	if err != nil {
+		return resp, errors.Wrap(err, "reconcile roles failed")
+	}
+	for _, drift := range drifts {
+		resp.Roles = append(resp.Roles, &v1.RoleDrift{
+			Role:   drift.Role,
+			Action: drift.Action,
+			Detail: drift.Detail,
+			Error:  drift.Error,
+		})
+	}
+	return resp, nil
+}
+
+// reconcileRoles loads the role templates, converges the roles and records
+// the managed roles in the HA config.
+func (p *DBPlugin) reconcileRoles(ctx context.Context, cluster *dcs.Cluster, dryRun bool) ([]mongodb.RoleDrift, error) {
+	path := mongodb.GetConfig().RoleTemplatesFile
+	if path == "" {
+		return nil, errors.New("role templates file is not configured")
+	}
+	if cluster.HaConfig == nil {
+		return nil, errors.New("cluster has no ha config")
+	}
+
+	templates, err := mongodb.LoadRoleTemplates(path)
+	if err != nil {
+		return nil, err
+	}
+
+	managed := cluster.HaConfig.ManagedRoles
+	drifts, newManaged, err := p.dbManager.ReconcileRoles(ctx, cluster, templates, managed, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun && !reflect.DeepEqual(managed, newManaged) {
+		cluster.HaConfig.ManagedRoles = newManaged
+		if err = p.store.UpdateHaConfig(); err != nil {
+			return drifts, errors.Wrap(err, "record managed roles failed")
+		}
+	}
+	return drifts, nil
+}
+
+func (p *DBPlugin) GetClientStats(ctx context.Context, in *v1.GetClientStatsRequest) (*v1.GetClientStatsResponse, error) {
+	resp := &v1.GetClientStatsResponse{}
+	for _, stats := range mongodb.GetClientRegistry().Stats() {
+		resp.Clients = append(resp.Clients, &v1.ClientStats{
+			Hosts:          stats.Hosts,
+			ReplsetName:    stats.ReplSetName,
+			Direct:         stats.Direct,
+			Authenticated:  stats.Authenticated,
+			Open:           stats.Open,
+			InUse:          stats.InUse,
+			Created:        stats.Created,
+			Closed:         stats.Closed,
+			CheckOutFailed: stats.CheckOutFailed,
+			LastUsed:       stats.LastUsed.Unix(),
+		})
+	}
+	return resp, nil
+}
+
+// RemoveShard drains the shard of the component out of the sharded cluster.
+// The removal is recorded in the HA config so that the reconciler keeps
+// driving it, and the shard is not added back once removed.
+func (p *DBPlugin) RemoveShard(ctx context.Context, in *v1.RemoveShardRequest) (*v1.RemoveShardResponse, error) {
+	resp := &v1.RemoveShardResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	removal, err := p.removeShard(ctx, cluster)
+	if err != nil {
+		return resp, errors.Wrap(err, "remove shard failed")
+	}
+	resp.Shard = removal.Shard
+	resp.State = removal.State
+	resp.RemainingChunks = int64(removal.RemainingChunks)
+	resp.JumboChunks = int64(removal.JumboChunks)
+	resp.DbsToMove = removal.DBsToMove
+	resp.Message = removal.Message
+	return resp, nil
+}
+
+// removeShard runs a step of the removal of the component's shard and
+// records its state in the HA config.
+func (p *DBPlugin) removeShard(ctx context.Context, cluster *dcs.Cluster) (*mongodb.ShardRemoval, error) {
+	if cluster.HaConfig == nil {
+		return nil, errors.New("cluster has no ha config")
+	}
+
+	shard := mongodb.GetConfig().ReplSetName
+	removal, err := p.dbManager.RemoveShard(ctx, shard)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	record := cluster.HaConfig.ShardRemoval
+	if record == nil {
+		record = &dcs.ShardRemovalRecord{Shard: shard, StartTime: now}
+		cluster.HaConfig.ShardRemoval = record
+	} else if record.State == removal.State {
+		return removal, nil
+	}
+	record.State = removal.State
+	record.UpdateTime = now
+	if err = p.store.UpdateHaConfig(); err != nil {
+		return removal, errors.Wrap(err, "record shard removal failed")
+	}
+	return removal, nil
+}
+
+// GetBalancerStatus returns the state of the balancer and its window.
+func (p *DBPlugin) GetBalancerStatus(ctx context.Context, in *v1.GetBalancerStatusRequest) (*v1.GetBalancerStatusResponse, error) {
+	resp := &v1.GetBalancerStatusResponse{}
+	status, window, err := p.dbManager.GetBalancer(ctx)
+	if err != nil {
+		return resp, errors.Wrap(err, "get balancer status failed")
+	}
+
+	resp.Mode = status.Mode
+	resp.InBalancerRound = status.InBalancerRound
+	if window != nil {
+		resp.WindowStart = window.Start
+		resp.WindowStop = window.Stop
+	}
+	return resp, nil
+}
+
+func (p *DBPlugin) StartBalancer(ctx context.Context, in *v1.StartBalancerRequest) (*v1.StartBalancerResponse, error) {
+	resp := &v1.StartBalancerResponse{}
+	if err := p.dbManager.StartBalancer(ctx); err != nil {
+		return resp, errors.Wrap(err, "start balancer failed")
+	}
+	return resp, nil
+}
+
+// StopBalancer stops the balancer and waits for the migrations in progress.
+func (p *DBPlugin) StopBalancer(ctx context.Context, in *v1.StopBalancerRequest) (*v1.StopBalancerResponse, error) {
+	resp := &v1.StopBalancerResponse{}
+	if in.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(in.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	if err := p.dbManager.StopBalancer(ctx); err != nil {
+		return resp, errors.Wrap(err, "stop balancer failed")
+	}
+	return resp, nil
+}
+
+func (p *DBPlugin) SetBalancerWindow(ctx context.Context, in *v1.SetBalancerWindowRequest) (*v1.SetBalancerWindowResponse, error) {
+	resp := &v1.SetBalancerWindowResponse{}
+	if err := p.dbManager.SetBalancerWindow(ctx, in.Start, in.Stop); err != nil {
+		return resp, errors.Wrap(err, "set balancer window failed")
+	}
+	return resp, nil
+}
+
+// GetVersions returns the versions of every member, a member that can not be
+// reached reports why.
+func (p *DBPlugin) GetVersions(ctx context.Context, in *v1.GetVersionsRequest) (*v1.GetVersionsResponse, error) {
+	resp := &v1.GetVersionsResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	for _, version := range p.dbManager.GetMemberVersions(ctx, cluster) {
+		resp.Members = append(resp.Members, &v1.MemberVersion{
+			Name:                        version.Name,
+			Host:                        version.Host,
+			Version:                     version.Version,
+			FeatureCompatibilityVersion: version.FeatureCompatibilityVersion,
+			Message:                     version.Message,
+		})
+	}
+
+	fcv, err := p.dbManager.GetFeatureCompatibilityVersion(ctx, cluster)
+	if err != nil {
+		return resp, errors.Wrap(err, "get featureCompatibilityVersion failed")
+	}
+	resp.FeatureCompatibilityVersion = fcv
+	return resp, nil
+}
+
+// CheckUpgrade reports why the upgrade is refused rather than failing, so
+// that callers can tell a refusal from an unreachable cluster.
+func (p *DBPlugin) CheckUpgrade(ctx context.Context, in *v1.CheckUpgradeRequest) (*v1.CheckUpgradeResponse, error) {
+	resp := &v1.CheckUpgradeResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	if err = p.dbManager.CheckUpgrade(ctx, cluster, in.TargetVersion); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	resp.Allowed = true
+	return resp, nil
+}
+
+func (p *DBPlugin) SetFeatureCompatibilityVersion(ctx context.Context, in *v1.SetFeatureCompatibilityVersionRequest) (*v1.SetFeatureCompatibilityVersionResponse, error) {
+	resp := &v1.SetFeatureCompatibilityVersionResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	previous, err := p.dbManager.SetFeatureCompatibilityVersion(ctx, cluster, in.Version)
+	if err != nil {
+		return resp, errors.Wrap(err, "set featureCompatibilityVersion failed")
+	}
+	resp.PreviousVersion = previous
+	resp.Version, err = p.dbManager.GetFeatureCompatibilityVersion(ctx, cluster)
+	if err != nil {
+		return resp, errors.Wrap(err, "get featureCompatibilityVersion failed")
+	}
+	return resp, nil
+}
+
+// RollingRestart reports the progress of the rolling restart recorded in the
+// HA config, which is started on request once the previous one completed, and
+// removes the record when it completes.
+func (p *DBPlugin) RollingRestart(ctx context.Context, in *v1.RollingRestartRequest) (*v1.RollingRestartResponse, error) {
+	resp := &v1.RollingRestartResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+	if cluster.HaConfig == nil {
+		return resp, errors.New("cluster has no ha config")
+	}
+
+	maxLag := in.MaxLagSeconds
+	if maxLag <= 0 {
+		maxLag = cluster.HaConfig.GetMaxLagOnSwitchover()
+	}
+	if maxLag <= 0 {
+		maxLag = mongodb.DefaultRestartMaxLag
+	}
+
+	record := cluster.HaConfig.RollingRestart
+	if in.Start && record != nil {
+		plan, err := p.dbManager.RollingRestart(ctx, cluster, record, maxLag)
+		if err != nil {
+			return resp, errors.Wrap(err, "rolling restart failed")
+		}
+		if !plan.Completed {
+			return resp, errors.Errorf("rolling restart started at %d is not completed", record.StartTime)
+		}
+	}
+	if in.Start {
+		members, err := p.dbManager.PlanRollingRestart(ctx, cluster)
+		if err != nil {
+			return resp, errors.Wrap(err, "plan rolling restart failed")
+		}
+		record = &dcs.RollingRestartRecord{Members: members, StartTime: time.Now().Unix()}
+		cluster.HaConfig.RollingRestart = record
+		if err = p.store.UpdateHaConfig(); err != nil {
+			return resp, errors.Wrap(err, "record rolling restart failed")
+		}
+	}
+	if record == nil {
+		return resp, errors.New("no rolling restart in progress")
+	}
+
+	plan, err := p.dbManager.RollingRestart(ctx, cluster, record, maxLag)
+	if err != nil {
+		return resp, errors.Wrap(err, "rolling restart failed")
+	}
+	if plan.Completed {
+		cluster.HaConfig.RollingRestart = nil
+		if err = p.store.UpdateHaConfig(); err != nil {
+			return resp, errors.Wrap(err, "clear rolling restart failed")
+		}
+	}
+
+	resp.StartTime = record.StartTime
+	resp.Completed = plan.Completed
+	for _, step := range plan.Steps {
+		resp.Steps = append(resp.Steps, &v1.RestartStep{
+			Member:     step.Member,
+			Host:       step.Host,
+			State:      step.State,
+			LagSeconds: step.LagSecs,
+			Status:     step.Status,
+			Message:    step.Message,
+		})
+	}
+	return resp, nil
+}
+
+// ResyncMember resyncs the member the plugin runs beside.
+func (p *DBPlugin) ResyncMember(ctx context.Context, in *v1.ResyncMemberRequest) (*v1.ResyncMemberResponse, error) {
+	resp := &v1.ResyncMemberResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+	if in.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(in.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	result, err := p.dbManager.ResyncMember(ctx, cluster, in.Force)
+	if err != nil {
+		return resp, errors.Wrap(err, "resync member failed")
+	}
+	resp.Member = result.Member
+	resp.State = result.State
+	resp.LagSeconds = result.LagSecs
+	resp.OplogWindowSeconds = result.OplogWindowSecs
+	resp.Message = result.Message
+	return resp, nil
+}
+
+func (p *DBPlugin) GetReplicationHealth(ctx context.Context, in *v1.GetReplicationHealthRequest) (*v1.GetReplicationHealthResponse, error) {
+	resp := &v1.GetReplicationHealthResponse{}
+	cluster, err := p.store.GetCluster()
+	if cluster == nil {
+		return resp, errors.Wrap(err, "get cluster failed")
+	}
+
+	health, err := p.dbManager.GetReplicationHealth(ctx, cluster, true)
+	if err != nil {
+		return resp, errors.Wrap(err, "get replication health failed")
+	}
+	for _, member := range health.Members {
+		resp.Members = append(resp.Members, &v1.MemberReplication{
+			Host:          member.Host,
+			State:         member.State,
+			Healthy:       member.Healthy,
+			LagSeconds:    member.LagSecs,
+			LagOperations: member.LagOps,
+		})
+	}
+	resp.OplogFirstTime = int64(health.OplogWindow.First.T)
+	resp.OplogLastTime = int64(health.OplogWindow.Last.T)
+	resp.OplogWindowHours = health.OplogWindowHours()
+	resp.Warnings = health.Warnings
 	return resp, nil
 }
@@ -0,0 +1,44 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/kubeblocks/pkg/kb_agent/plugin"
+	"github.com/apecloud/mongodb_plugin/mongodb"
+)
+
+func TestIsEngineReady(t *testing.T) {
+	old := mongodb.GetConfig()
+	t.Cleanup(func() { mongodb.SwapConfig(old) })
+	config, err := mongodb.NewConfig(map[string]string{})
+	assert.Nil(t, err)
+	config.Hosts = []string{"127.0.0.1:1"}
+
+	// ready once started, but not when mongod stopped answering
+	p := &DBPlugin{dbManager: &mongodb.Manager{DBStartupReady: true}}
+	resp, err := p.IsEngineReady(context.Background(), &plugin.IsEngineReadyRequest{})
+	assert.Nil(t, err)
+	assert.False(t, resp.Ready)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+	"github.com/apecloud/mongodb_plugin/mongodb"
+)
+
+// reconciler periodically drives the work recorded in the DCS. It only acts
+// on the member that is currently the primary.
+type reconciler struct {
+	plugin   *DBPlugin
+	interval time.Duration
+	// shardRegistered is set once the replica set is known to be a shard,
+	// so that mongos is not asked again.
+	shardRegistered bool
+}
+
+func newReconciler(plugin *DBPlugin, interval time.Duration) *reconciler {
+	return &reconciler{
+		plugin:   plugin,
+		interval: interval,
+	}
+}
+
+func (r *reconciler) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+func (r *reconciler) reconcile(ctx context.Context) {
+	if r.plugin.dbManager == nil || r.plugin.store == nil {
+		return
+	}
+
+	// nothing is reconciled through a mongod that is down or stuck behind a
+	// lock, a reconfig or a switchover would hang on it
+	if !r.plugin.dbManager.IsRunning() {
+		return
+	}
+
+	isLeader, err := r.plugin.dbManager.IsLeader(ctx, nil)
+	if err != nil || !isLeader {
+		return
+	}
+
+	cluster, err := r.plugin.store.GetCluster()
+	if err != nil {
+		logger.Info("Reconcile get cluster failed", "error", err.Error())
+		return
+	}
+
+	r.reconcileSwitchover(ctx, cluster)
+	r.reconcileMemberAttributes(ctx, cluster)
+	r.reconcileRoles(ctx, cluster)
+	r.reconcileShard(ctx, cluster)
+}
+
+// reconcileShard adds the replica set to the sharded cluster once it is
+// healthy, or drives the removal of its shard once it is started. Only
+// shards are added, neither config servers nor plain replica sets are.
+func (r *reconciler) reconcileShard(ctx context.Context, cluster *dcs.Cluster) {
+	if len(mongodb.GetConfig().MongosHosts) == 0 || cluster.HaConfig == nil {
+		return
+	}
+	role, err := r.plugin.dbManager.GetComponentRole(ctx, cluster)
+	if err != nil || role != mongodb.ComponentRoleShard {
+		return
+	}
+
+	if removal := cluster.HaConfig.ShardRemoval; removal != nil {
+		if removal.State == mongodb.ShardRemoveCompleted {
+			return
+		}
+		progress, err := r.plugin.removeShard(ctx, cluster)
+		if err != nil {
+			logger.Info("Remove shard failed", "shard", removal.Shard, "error", err.Error())
+			return
+		}
+		logger.Info("Removing shard", "shard", progress.Shard, "state", progress.State,
+			"chunks", progress.RemainingChunks, "dbsToMove", progress.DBsToMove, "message", progress.Message)
+		return
+	}
+
+	if r.shardRegistered || !r.plugin.dbManager.IsClusterHealthy(ctx, cluster) {
+		return
+	}
+	added, err := r.plugin.dbManager.RegisterShard(ctx, cluster)
+	if err != nil {
+		logger.Info("Register shard failed", "error", err.Error())
+		return
+	}
+	if added {
+		logger.Info("Shard registered with mongos")
+	}
+	r.shardRegistered = true
+}
+
+// reconcileRoles converges the custom roles to the role templates if they
+// are configured, and logs the roles that drifted.
+func (r *reconciler) reconcileRoles(ctx context.Context, cluster *dcs.Cluster) {
+	if mongodb.GetConfig().RoleTemplatesFile == "" {
+		return
+	}
+
+	drifts, err := r.plugin.reconcileRoles(ctx, cluster, false)
+	if err != nil {
+		logger.Info("Reconcile roles failed", "error", err.Error())
+	}
+	for _, drift := range drifts {
+		if drift.Action == mongodb.RoleInSync {
+			continue
+		}
+		logger.Info("Role drifted", "role", drift.Role, "action", drift.Action, "detail", drift.Detail, "error", drift.Error)
+	}
+}
+
+// reconcileMemberAttributes applies the hidden and delayed settings declared
+// on the pods to the replica set config.
+func (r *reconciler) reconcileMemberAttributes(ctx context.Context, cluster *dcs.Cluster) {
+	if cluster.Switchover != nil {
+		return
+	}
+	if err := r.plugin.dbManager.ReconcileMemberAttributes(ctx, cluster); err != nil {
+		logger.Info("Reconcile member attributes failed", "error", err.Error())
+	}
+}
+
+// switchoverTimeout is how long a running switchover may take before its
+// record is considered stale. A switchover waits twice for the step down,
+// the margin covers the reconfigurations around it.
+func switchoverTimeout() time.Duration {
+	return 2*time.Duration(mongodb.GetConfig().StepDownSecs)*time.Second + 5*time.Minute
+}
+
+// reconcileSwitchover runs the pending switchover once it is due, and fails
+// the running one once it is stale.
+func (r *reconciler) reconcileSwitchover(ctx context.Context, cluster *dcs.Cluster) {
+	switchover := cluster.Switchover
+	if switchover == nil {
+		return
+	}
+
+	switch switchover.Status {
+	case dcs.SwitchoverSucceeded, dcs.SwitchoverFailed:
+		// the outcome is recorded but the record was not deleted
+		if err := r.plugin.store.DeleteSwitchover(); err != nil {
+			logger.Info("Delete finished switchover failed", "error", err.Error())
+		}
+		return
+	case dcs.SwitchoverRunning:
+		// the plugin running it may have restarted, so that the record would
+		// block every later switchover
+		if switchover.IsStale(time.Now().Unix(), int64(switchoverTimeout().Seconds())) {
+			logger.Info("Fail stale switchover", "primary", switchover.Leader, "candidate", switchover.Candidate, "startTime", switchover.StartTime)
+			switchover.Status = dcs.SwitchoverFailed
+			switchover.Reason = "switchover did not finish in time"
+			if err := r.plugin.store.UpdateSwitchover(switchover); err != nil {
+				logger.Info("Record switchover outcome failed", "error", err.Error())
+			}
+			if err := r.plugin.store.DeleteSwitchover(); err != nil {
+				logger.Info("Delete stale switchover failed", "error", err.Error())
+			}
+		}
+		return
+	}
+
+	if !switchover.IsDue(time.Now().Unix()) {
+		return
+	}
+
+	if cluster.HaConfig == nil || !cluster.HaConfig.IsEnable() {
+		switchover.Status = dcs.SwitchoverFailed
+		switchover.Reason = "cluster's ha is disabled"
+		if err := r.plugin.store.UpdateSwitchover(switchover); err != nil {
+			logger.Info("Record switchover outcome failed", "error", err.Error())
+		}
+		if err := r.plugin.store.DeleteSwitchover(); err != nil {
+			logger.Info("Delete switchover failed", "error", err.Error())
+		}
+		return
+	}
+
+	logger.Info("Run scheduled switchover", "primary", switchover.Leader, "candidate", switchover.Candidate, "scheduledAt", switchover.ScheduledAt)
+	if err := r.plugin.executeSwitchover(ctx, cluster, switchover); err != nil {
+		logger.Info("Scheduled switchover failed", "error", err.Error())
+	}
+}
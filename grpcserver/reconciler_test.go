@@ -0,0 +1,137 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+	"github.com/apecloud/mongodb_plugin/mongodb"
+)
+
+// switchoverStore records the switchover calls and fails the updates if
+// claimErr is set, as the k8s store does when another one claimed first.
+type switchoverStore struct {
+	dcs.DCS
+	claimErr error
+	updates  []dcs.Switchover
+	deleted  bool
+}
+
+func (s *switchoverStore) UpdateSwitchover(switchover *dcs.Switchover) error {
+	if s.claimErr != nil {
+		return s.claimErr
+	}
+	s.updates = append(s.updates, *switchover)
+	return nil
+}
+
+func (s *switchoverStore) DeleteSwitchover() error {
+	s.deleted = true
+	return nil
+}
+
+func TestExecuteSwitchoverClaim(t *testing.T) {
+	store := &switchoverStore{claimErr: errors.New("the object has been modified")}
+	plugin := &DBPlugin{store: store}
+	switchover := &dcs.Switchover{Leader: "pod-0", Candidate: "pod-1", Status: dcs.SwitchoverPending}
+
+	err := plugin.executeSwitchover(context.Background(), nil, switchover)
+	assert.ErrorContains(t, err, "claim switchover failed")
+	assert.False(t, store.deleted)
+}
+
+func TestReconcileStaleSwitchover(t *testing.T) {
+	old := mongodb.GetConfig()
+	_, err := mongodb.NewConfig(map[string]string{})
+	assert.Nil(t, err)
+	t.Cleanup(func() { mongodb.SwapConfig(old) })
+
+	now := time.Now().Unix()
+	timeout := int64(switchoverTimeout().Seconds())
+
+	t.Run("running switchover in time", func(t *testing.T) {
+		store := &switchoverStore{}
+		r := newReconciler(&DBPlugin{store: store}, time.Second)
+		switchover := &dcs.Switchover{Status: dcs.SwitchoverRunning, StartTime: now - timeout + 10}
+
+		r.reconcileSwitchover(context.Background(), &dcs.Cluster{Switchover: switchover})
+		assert.Empty(t, store.updates)
+		assert.False(t, store.deleted)
+	})
+
+	t.Run("stale running switchover", func(t *testing.T) {
+		store := &switchoverStore{}
+		r := newReconciler(&DBPlugin{store: store}, time.Second)
+		switchover := &dcs.Switchover{Status: dcs.SwitchoverRunning, StartTime: now - timeout - 10}
+
+		r.reconcileSwitchover(context.Background(), &dcs.Cluster{Switchover: switchover})
+		assert.Len(t, store.updates, 1)
+		assert.Equal(t, dcs.SwitchoverFailed, store.updates[0].Status)
+		assert.NotEmpty(t, store.updates[0].Reason)
+		assert.True(t, store.deleted)
+	})
+
+	t.Run("running switchover without start time", func(t *testing.T) {
+		store := &switchoverStore{}
+		r := newReconciler(&DBPlugin{store: store}, time.Second)
+
+		r.reconcileSwitchover(context.Background(), &dcs.Cluster{Switchover: &dcs.Switchover{Status: dcs.SwitchoverRunning}})
+		assert.True(t, store.deleted)
+	})
+}
+
+func TestReconcileShardRole(t *testing.T) {
+	old := mongodb.GetConfig()
+	t.Cleanup(func() { mongodb.SwapConfig(old) })
+
+	mongosUsed := func() bool {
+		for _, stats := range mongodb.GetClientRegistry().Stats() {
+			if len(stats.Hosts) == 1 && stats.Hosts[0] == "127.0.0.1:1" {
+				return true
+			}
+		}
+		return false
+	}
+	reconcileShard := func(role string) {
+		_, err := mongodb.NewConfig(map[string]string{"mongosHosts": "127.0.0.1:1", "componentRole": role})
+		assert.Nil(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		r := newReconciler(&DBPlugin{dbManager: &mongodb.Manager{}, store: &switchoverStore{}}, time.Second)
+		r.reconcileShard(ctx, &dcs.Cluster{HaConfig: &dcs.HaConfig{
+			ShardRemoval: &dcs.ShardRemovalRecord{Shard: "rs0", State: mongodb.ShardRemoveOngoing},
+		}})
+	}
+
+	reconcileShard(mongodb.ComponentRoleReplSet)
+	assert.False(t, mongosUsed())
+	reconcileShard(mongodb.ComponentRoleConfigServer)
+	assert.False(t, mongosUsed())
+
+	reconcileShard(mongodb.ComponentRoleShard)
+	assert.True(t, mongosUsed())
+}
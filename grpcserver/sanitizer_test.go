@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	"github.com/apecloud/kubeblocks/pkg/kb_agent/plugin"
+	v1 "github.com/apecloud/mongodb_plugin/api/v1"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -53,6 +54,7 @@ func TestStripSecrets(t *testing.T) {
 		{false, "false"},
 		{&plugin.GetRoleRequest{}, `{}`},
 		{getRoleReq, `{"engine_info":{"admin_password":"***stripped***","admin_user":"admin","fqdn":"fqdn","port":"1024"}}`},
+		{&v1.RotateRootPasswordRequest{Password: secretValue}, `{"password":"***stripped***"}`},
 	}
 
 	// Message from revised spec as received by a sidecar based on the current spec.
@@ -30,6 +30,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/apecloud/kubeblocks/pkg/kb_agent/plugin"
+	v1 "github.com/apecloud/mongodb_plugin/api/v1"
 )
 
 // NonBlockingGRPCServer Defines Non blocking GRPC server interfaces
@@ -95,12 +96,15 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, enginePlugin plugin.Engin
 	}
 
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(s.logGRPC),
+		grpc.ChainUnaryInterceptor(s.logGRPC, observeGRPC),
 	}
 	server := grpc.NewServer(opts...)
 	s.server = server
 
 	plugin.RegisterEnginePluginServer(server, enginePlugin)
+	if mongodbPlugin, ok := enginePlugin.(v1.MongoDBPluginServer); ok {
+		v1.RegisterMongoDBPluginServer(server, mongodbPlugin)
+	}
 
 	s.logger.Info("Listening for connections on address", "addr", listener.Addr())
 
@@ -128,4 +132,10 @@ func StartNonBlocking() {
 	dbPlugin := NewDBPlugin()
 	listenAddr := fmt.Sprintf("tcp://%s:%d", config.Address, config.Port)
 	NewNonBlockingGRPCServer(logger).Start(listenAddr, dbPlugin)
+	metricsRegistry.MustRegister(newReplicationCollector(dbPlugin), newMemberCollector(dbPlugin))
+	if config.MetricsPort > 0 {
+		go serveMetrics(config.Address, config.MetricsPort)
+	}
+
+	go newReconciler(dbPlugin, config.ReconcileInterval).run(context.Background())
 }
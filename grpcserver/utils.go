@@ -21,7 +21,9 @@ package grpcserver
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -47,3 +49,19 @@ func GetEngineInfo() *plugin.EngineInfo {
 		AdminPassword: viper.GetString(constant.KBEnvServicePassword),
 	}
 }
+
+// ParseScheduledAt parses a unix timestamp in seconds or an RFC3339 time, an
+// empty string means now.
+func ParseScheduledAt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ts, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scheduled time: %v", s)
+	}
+	return t.Unix(), nil
+}
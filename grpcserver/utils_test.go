@@ -21,6 +21,7 @@ package grpcserver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -77,3 +78,20 @@ func TestParseEndpoint(t *testing.T) {
 	_, _, err = ParseEndpoint("")
 	assert.NotNil(t, err)
 }
+
+func TestParseScheduledAt(t *testing.T) {
+	ts, err := ParseScheduledAt("")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ts)
+
+	ts, err = ParseScheduledAt("1700000000")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1700000000), ts)
+
+	ts, err = ParseScheduledAt("2023-11-14T22:13:20Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0).Unix(), ts)
+
+	_, err = ParseScheduledAt("tomorrow")
+	assert.NotNil(t, err)
+}
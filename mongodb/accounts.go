@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// The role names KubeBlocks uses for accounts.
+const (
+	SuperUserRole = "superuser"
+	ReadWriteRole = "readwrite"
+	ReadOnlyRole  = "readonly"
+)
+
+var builtinRoles = map[string]string{
+	SuperUserRole: "root",
+	ReadWriteRole: "readWriteAnyDatabase",
+	ReadOnlyRole:  "readAnyDatabase",
+}
+
+// BuiltinRoles maps a KubeBlocks role name to the MongoDB built-in roles
+// granted on the admin database. An empty role grants none.
+func BuiltinRoles(kbRole string) ([]map[string]interface{}, error) {
+	roles := []map[string]interface{}{}
+	if kbRole == "" {
+		return roles, nil
+	}
+
+	role, ok := builtinRoles[strings.ToLower(kbRole)]
+	if !ok {
+		return nil, errors.Errorf("role %s is not supported", kbRole)
+	}
+	roles = append(roles, map[string]interface{}{
+		"role": role,
+		"db":   "admin",
+	})
+	return roles, nil
+}
+
+// ProvisionAccount creates the user on the primary, or updates its password,
+// and its roles if a role is given, when it already exists.
+func (mgr *Manager) ProvisionAccount(ctx context.Context, cluster *dcs.Cluster, userName, password, kbRole string) error {
+	if userName == "" || password == "" {
+		return errors.New("user name and password must be set")
+	}
+
+	roles, err := BuiltinRoles(kbRole)
+	if err != nil {
+		return err
+	}
+
+	client, err := mgr.GetLeaderClient(ctx, cluster)
+	if err != nil {
+		mgr.Logger.Info("Get leader client failed", "error", err.Error())
+		return err
+	}
+
+	user, err := GetUser(ctx, client, userName)
+	if err != nil {
+		return errors.Wrap(err, "get user")
+	}
+
+	if user == nil {
+		mgr.Logger.Info("Create user", "user", userName, "role", kbRole)
+		return CreateUser(ctx, client, userName, password, roles...)
+	}
+
+	mgr.Logger.Info("User exists, update it", "user", userName, "role", kbRole)
+	if err = UpdateUserPass(ctx, client, userName, password); err != nil {
+		return errors.Wrap(err, "update user password")
+	}
+	if kbRole == "" {
+		return nil
+	}
+	if err = UpdateUserRoles(ctx, client, userName, roles); err != nil {
+		return errors.Wrap(err, "update user roles")
+	}
+	return nil
+}
+
+// ListAccounts returns the users of all databases with their roles and
+// authentication mechanisms.
+func (mgr *Manager) ListAccounts(ctx context.Context) ([]User, error) {
+	users, err := ListUsers(ctx, mgr.GetClient())
+	if err != nil {
+		mgr.Logger.Info("List users failed", "error", err.Error())
+		return nil, err
+	}
+	return users, nil
+}
+
+// DescribeAccount returns the user of the database, admin if empty, with the
+// roles it is granted and their effective privileges.
+func (mgr *Manager) DescribeAccount(ctx context.Context, userName, db string) (*User, []Role, error) {
+	if db == "" {
+		db = "admin"
+	}
+
+	user, err := GetDBUser(ctx, mgr.GetClient(), userName, db)
+	if err != nil {
+		mgr.Logger.Info("Get user failed", "error", err.Error())
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.Errorf("user %s.%s not exists", db, userName)
+	}
+	if len(user.Roles) == 0 {
+		return user, nil, nil
+	}
+
+	roles, err := GetRolesInfo(ctx, mgr.GetClient(), user.Roles)
+	if err != nil {
+		mgr.Logger.Info("Get roles info failed", "error", err.Error())
+		return nil, nil, err
+	}
+	return user, roles, nil
+}
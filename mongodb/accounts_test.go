@@ -0,0 +1,47 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinRoles(t *testing.T) {
+	roles, err := BuiltinRoles("superuser")
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{{"role": "root", "db": "admin"}}, roles)
+
+	roles, err = BuiltinRoles("ReadWrite")
+	assert.Nil(t, err)
+	assert.Equal(t, "readWriteAnyDatabase", roles[0]["role"])
+
+	roles, err = BuiltinRoles("readonly")
+	assert.Nil(t, err)
+	assert.Equal(t, "readAnyDatabase", roles[0]["role"])
+
+	roles, err = BuiltinRoles("")
+	assert.Nil(t, err)
+	assert.Empty(t, roles)
+
+	_, err = BuiltinRoles("custom")
+	assert.NotNil(t, err)
+}
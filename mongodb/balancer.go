@@ -0,0 +1,220 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	balancerPollInterval = time.Second
+	// balancerIdleTimeout bounds the stop of the balancer, balancerStop itself
+	// waits for the balancing round to finish, if the caller set no deadline.
+	balancerIdleTimeout = 10 * time.Minute
+
+	balancerWindowLayout = "15:04"
+)
+
+// GetBalancer returns the state of the balancer and its window, nil if it
+// may run at any time.
+func (mgr *Manager) GetBalancer(ctx context.Context) (*BalancerStatus, *BalancerWindow, error) {
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status, err := GetBalancerStatus(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	window, err := GetBalancerWindow(ctx, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return status, window, nil
+}
+
+func (mgr *Manager) StartBalancer(ctx context.Context) error {
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr.Logger.Info("start balancer")
+	return StartBalancer(ctx, client)
+}
+
+// StopBalancer disables the balancer and waits until the balancing round in
+// progress, and with it any chunk migration, has finished.
+func (mgr *Manager) StopBalancer(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, balancerIdleTimeout)
+		defer cancel()
+	}
+
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr.Logger.Info("stop balancer")
+	if err = StopBalancer(ctx, client); err != nil {
+		return err
+	}
+
+	for {
+		status, err := GetBalancerStatus(ctx, client)
+		if err == nil && !status.InBalancerRound {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for the balancing round to finish")
+		case <-time.After(balancerPollInterval):
+		}
+	}
+}
+
+// SetBalancerWindow restricts the balancer to the daily window between start
+// and stop, given as HH:MM. The restriction is lifted if both are empty.
+func (mgr *Manager) SetBalancerWindow(ctx context.Context, start, stop string) error {
+	window, err := newBalancerWindow(start, stop)
+	if err != nil {
+		return err
+	}
+
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr.Logger.Info("set balancer window", "start", start, "stop", stop)
+	return SetBalancerWindow(ctx, client, window)
+}
+
+func newBalancerWindow(start, stop string) (*BalancerWindow, error) {
+	if start == "" && stop == "" {
+		return nil, nil
+	}
+	for _, t := range []string{start, stop} {
+		if _, err := time.Parse(balancerWindowLayout, t); err != nil {
+			return nil, errors.Errorf("balancer window bounds must be HH:MM, got %q", t)
+		}
+	}
+	if start == stop {
+		return nil, errors.New("balancer window must not be empty")
+	}
+	return &BalancerWindow{Start: start, Stop: stop}, nil
+}
+
+// GetBalancerStatus returns the balancer state, the client MUST be connected
+// to mongos.
+func GetBalancerStatus(ctx context.Context, client *mongo.Client) (*BalancerStatus, error) {
+	resp := &BalancerStatus{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "balancerStatus", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "balancerStatus")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode balancerStatus response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+func StartBalancer(ctx context.Context, client *mongo.Client) error {
+	return runBalancerCommand(ctx, client, "balancerStart")
+}
+
+// StopBalancer disables the balancer, the server waits for the balancing
+// round in progress for a while but may return before it has finished.
+func StopBalancer(ctx context.Context, client *mongo.Client) error {
+	return runBalancerCommand(ctx, client, "balancerStop")
+}
+
+func runBalancerCommand(ctx context.Context, client *mongo.Client, command string) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: command, Value: 1}})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), command)
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrapf(err, "failed to decode %s response", command)
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
+
+// GetBalancerWindow returns the window stored in the balancer settings, nil
+// if there is none.
+func GetBalancerWindow(ctx context.Context, client *mongo.Client) (*BalancerWindow, error) {
+	settings := struct {
+		ActiveWindow *BalancerWindow `bson:"activeWindow,omitempty"`
+	}{}
+
+	res := client.Database("config").Collection("settings").FindOne(ctx, bson.D{{Key: "_id", Value: "balancer"}})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "get balancer settings")
+	}
+
+	if err := res.Decode(&settings); err != nil {
+		return nil, errors.Wrap(err, "failed to decode balancer settings")
+	}
+	return settings.ActiveWindow, nil
+}
+
+// SetBalancerWindow stores the window in the balancer settings, or removes
+// it if window is nil.
+func SetBalancerWindow(ctx context.Context, client *mongo.Client, window *BalancerWindow) error {
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "activeWindow", Value: ""}}}}
+	if window != nil {
+		update = bson.D{{Key: "$set", Value: bson.D{{Key: "activeWindow", Value: window}}}}
+	}
+
+	_, err := client.Database("config").Collection("settings").UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: "balancer"}}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.Wrap(err, "update balancer settings")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewBalancerWindow(t *testing.T) {
+	window, err := newBalancerWindow("23:00", "06:00")
+	assert.Nil(t, err)
+	assert.Equal(t, &BalancerWindow{Start: "23:00", Stop: "06:00"}, window)
+
+	window, err = newBalancerWindow("", "")
+	assert.Nil(t, err)
+	assert.Nil(t, window)
+
+	_, err = newBalancerWindow("23:00", "")
+	assert.EqualError(t, err, `balancer window bounds must be HH:MM, got ""`)
+
+	_, err = newBalancerWindow("25:00", "06:00")
+	assert.EqualError(t, err, `balancer window bounds must be HH:MM, got "25:00"`)
+
+	_, err = newBalancerWindow("06:00", "06:00")
+	assert.EqualError(t, err, "balancer window must not be empty")
+}
+
+func TestBalancerStatusDecode(t *testing.T) {
+	raw, err := bson.Marshal(bson.M{"mode": "full", "inBalancerRound": true, "numBalancerRounds": int64(42), "ok": 1})
+	assert.Nil(t, err)
+
+	status := &BalancerStatus{}
+	assert.Nil(t, bson.Unmarshal(raw, status))
+	assert.Equal(t, "full", status.Mode)
+	assert.True(t, status.InBalancerRound)
+	assert.Equal(t, int64(42), status.NumBalancerRounds)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CandidateRejection records why a replica set member can not be elected.
+type CandidateRejection struct {
+	Member string `json:"member"`
+	Reason string `json:"reason"`
+}
+
+// NoCandidateError is returned when no member qualifies as the new primary.
+type NoCandidateError struct {
+	Candidate  string               `json:"candidate,omitempty"`
+	Rejections []CandidateRejection `json:"rejections"`
+}
+
+func (e *NoCandidateError) Error() string {
+	reasons := make([]string, 0, len(e.Rejections))
+	for _, r := range e.Rejections {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", r.Member, r.Reason))
+	}
+	if e.Candidate != "" {
+		return fmt.Sprintf("candidate %s is not electable: [%s]", e.Candidate, strings.Join(reasons, ", "))
+	}
+	return fmt.Sprintf("no electable member: [%s]", strings.Join(reasons, ", "))
+}
+
+// Candidate is an electable member together with its replication lag.
+type Candidate struct {
+	Status *Member
+	Config *ConfigMember
+	// Lag is the optime distance to the primary in seconds.
+	Lag int64
+}
+
+// RankCandidates filters out the members that must never become primary and
+// sorts the remaining ones by their optime distance from the primary, the
+// closest first. A maxLag less than or equal to zero disables the lag check.
+func RankCandidates(status *ReplSetStatus, rsConfig *RSConfig, maxLag int64) ([]Candidate, []CandidateRejection) {
+	configMembers := make(map[int]*ConfigMember, len(rsConfig.Members))
+	for i := range rsConfig.Members {
+		configMembers[rsConfig.Members[i].ID] = &rsConfig.Members[i]
+	}
+
+	latest := latestOptime(status)
+	candidates := make([]Candidate, 0, len(status.Members))
+	var rejections []CandidateRejection
+	reject := func(member *Member, reason string) {
+		rejections = append(rejections, CandidateRejection{Member: member.Name, Reason: reason})
+	}
+
+	for _, member := range status.Members {
+		if member == nil {
+			continue
+		}
+		configMember, ok := configMembers[member.ID]
+		if !ok {
+			reject(member, "not in replica set config")
+			continue
+		}
+		if reason := configMember.ineligibleReason(); reason != "" {
+			reject(member, reason)
+			continue
+		}
+		if member.Health != MemberHealthUp {
+			reject(member, "unhealthy")
+			continue
+		}
+		if member.State != MemberStatePrimary && member.State != MemberStateSecondary {
+			reject(member, fmt.Sprintf("state is %s", member.StateStr))
+			continue
+		}
+
+		lag := optimeLag(latest, member.Optime)
+		if maxLag > 0 && lag > maxLag {
+			reject(member, fmt.Sprintf("lag %ds exceeds max lag %ds", lag, maxLag))
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Status: member,
+			Config: configMember,
+			Lag:    lag,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Lag != b.Lag {
+			return a.Lag < b.Lag
+		}
+		if c := compareOptime(a.Status.OptimeDurable, b.Status.OptimeDurable); c != 0 {
+			return c > 0
+		}
+		if a.Config.Priority != b.Config.Priority {
+			return a.Config.Priority > b.Config.Priority
+		}
+		return a.Status.Name < b.Status.Name
+	})
+	return candidates, rejections
+}
+
+// ineligibleReason returns why the member can never be elected, or an empty
+// string if it is electable.
+func (m *ConfigMember) ineligibleReason() string {
+	switch {
+	case m.ArbiterOnly != nil && *m.ArbiterOnly:
+		return "arbiter"
+	case m.Hidden != nil && *m.Hidden:
+		return "hidden"
+	case m.GetDelaySecs() > 0:
+		return "delayed"
+	case m.Priority <= 0:
+		return "priority is 0"
+	}
+	return ""
+}
+
+// GetDelaySecs returns the replication delay of the member, whichever field
+// the server version uses to store it.
+func (m *ConfigMember) GetDelaySecs() int64 {
+	if m.SecondaryDelaySecs != nil {
+		return *m.SecondaryDelaySecs
+	}
+	if m.SlaveDelay != nil {
+		return *m.SlaveDelay
+	}
+	return 0
+}
+
+// latestOptime returns the primary's optime, or the most recent optime of any
+// healthy member when the replica set has no primary.
+func latestOptime(status *ReplSetStatus) *Optime {
+	if primary := status.Primary(); primary != nil && primary.Optime != nil {
+		return primary.Optime
+	}
+
+	var latest *Optime
+	for _, member := range status.Members {
+		if member == nil || member.Health != MemberHealthUp {
+			continue
+		}
+		if compareOptime(member.Optime, latest) > 0 {
+			latest = member.Optime
+		}
+	}
+	return latest
+}
+
+// optimeLag returns how many seconds the optime is behind the latest optime.
+func optimeLag(latest, optime *Optime) int64 {
+	if latest == nil {
+		return 0
+	}
+	if optime == nil {
+		return int64(latest.Timestamp.T)
+	}
+	lag := int64(latest.Timestamp.T) - int64(optime.Timestamp.T)
+	if lag < 0 {
+		return 0
+	}
+	return lag
+}
+
+// compareOptime orders optimes by term first, then by timestamp. A nil optime
+// is older than any other optime.
+func compareOptime(a, b *Optime) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.Term != b.Term:
+		if a.Term > b.Term {
+			return 1
+		}
+		return -1
+	}
+	return primitive.CompareTimestamp(a.Timestamp, b.Timestamp)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func mockOptime(secs uint32) *Optime {
+	return &Optime{Timestamp: primitive.Timestamp{T: secs, I: 1}, Term: 1}
+}
+
+func mockStatusMember(id int, name string, state MemberState, secs uint32) *Member {
+	return &Member{
+		ID:            id,
+		Name:          name,
+		Health:        MemberHealthUp,
+		State:         state,
+		StateStr:      MemberStateStrings[state],
+		Optime:        mockOptime(secs),
+		OptimeDurable: mockOptime(secs),
+	}
+}
+
+func TestRankCandidates(t *testing.T) {
+	yes := true
+	delay := int64(3600)
+	rsConfig := &RSConfig{
+		Members: ConfigMembers{
+			{ID: 0, Host: "pod-0:27017", Priority: PrimaryPriority},
+			{ID: 1, Host: "pod-1:27017", Priority: SecondaryPriority},
+			{ID: 2, Host: "pod-2:27017", Priority: SecondaryPriority},
+			{ID: 3, Host: "pod-3:27017", Priority: 0, Hidden: &yes},
+			{ID: 4, Host: "pod-4:27017", Priority: 0, SecondaryDelaySecs: &delay},
+			{ID: 5, Host: "pod-5:27017", ArbiterOnly: &yes},
+			{ID: 6, Host: "pod-6:27017", Priority: 0},
+			{ID: 7, Host: "pod-7:27017", Priority: SecondaryPriority},
+		},
+	}
+
+	t.Run("excludes non electable members and sorts by lag", func(t *testing.T) {
+		status := &ReplSetStatus{
+			Members: []*Member{
+				mockStatusMember(0, "pod-0:27017", MemberStatePrimary, 1000),
+				mockStatusMember(1, "pod-1:27017", MemberStateSecondary, 990),
+				mockStatusMember(2, "pod-2:27017", MemberStateSecondary, 998),
+				mockStatusMember(3, "pod-3:27017", MemberStateSecondary, 1000),
+				mockStatusMember(4, "pod-4:27017", MemberStateSecondary, 1000),
+				mockStatusMember(5, "pod-5:27017", MemberStateArbiter, 0),
+				mockStatusMember(6, "pod-6:27017", MemberStateSecondary, 1000),
+				mockStatusMember(7, "pod-7:27017", MemberStateRecovering, 1000),
+			},
+		}
+
+		candidates, rejections := RankCandidates(status, rsConfig, 0)
+		names := make([]string, 0, len(candidates))
+		for _, c := range candidates {
+			names = append(names, c.Status.Name)
+		}
+		assert.Equal(t, []string{"pod-0:27017", "pod-2:27017", "pod-1:27017"}, names)
+		assert.Equal(t, int64(2), candidates[1].Lag)
+		assert.Equal(t, int64(10), candidates[2].Lag)
+
+		reasons := map[string]string{}
+		for _, r := range rejections {
+			reasons[r.Member] = r.Reason
+		}
+		assert.Equal(t, map[string]string{
+			"pod-3:27017": "hidden",
+			"pod-4:27017": "delayed",
+			"pod-5:27017": "arbiter",
+			"pod-6:27017": "priority is 0",
+			"pod-7:27017": "state is RECOVERING",
+		}, reasons)
+	})
+
+	t.Run("honors max lag", func(t *testing.T) {
+		status := &ReplSetStatus{
+			Members: []*Member{
+				mockStatusMember(0, "pod-0:27017", MemberStatePrimary, 1000),
+				mockStatusMember(1, "pod-1:27017", MemberStateSecondary, 990),
+				mockStatusMember(2, "pod-2:27017", MemberStateSecondary, 998),
+			},
+		}
+
+		candidates, rejections := RankCandidates(status, rsConfig, 5)
+		assert.Len(t, candidates, 2)
+		assert.Equal(t, []CandidateRejection{{Member: "pod-1:27017", Reason: "lag 10s exceeds max lag 5s"}}, rejections)
+	})
+
+	t.Run("uses the latest healthy optime without primary", func(t *testing.T) {
+		down := mockStatusMember(0, "pod-0:27017", MemberStateDown, 2000)
+		down.Health = MemberHealthDown
+		status := &ReplSetStatus{
+			Members: []*Member{
+				down,
+				mockStatusMember(1, "pod-1:27017", MemberStateSecondary, 990),
+				mockStatusMember(2, "pod-2:27017", MemberStateSecondary, 998),
+			},
+		}
+
+		candidates, rejections := RankCandidates(status, rsConfig, 0)
+		assert.Len(t, candidates, 2)
+		assert.Equal(t, "pod-2:27017", candidates[0].Status.Name)
+		assert.Equal(t, int64(0), candidates[0].Lag)
+		assert.Equal(t, int64(8), candidates[1].Lag)
+		assert.Equal(t, []CandidateRejection{{Member: "pod-0:27017", Reason: "unhealthy"}}, rejections)
+	})
+}
+
+func TestNoCandidateError(t *testing.T) {
+	err := &NoCandidateError{
+		Candidate:  "pod-1",
+		Rejections: []CandidateRejection{{Member: "pod-1:27017", Reason: "hidden"}},
+	}
+	assert.Equal(t, "candidate pod-1 is not electable: [pod-1:27017: hidden]", err.Error())
+
+	err = &NoCandidateError{}
+	assert.Equal(t, "no electable member: []", err.Error())
+}
@@ -21,29 +21,61 @@ package mongodb
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/auth"
 )
 
+// NewMongodbClient connects with the config's credentials. While a root
+// password rotation is in its grace period, the previous password is tried
+// if the server rejects the new one, since not every member may have
+// replicated the change yet.
 func NewMongodbClient(ctx context.Context, config *Config) (*mongo.Client, error) {
+	return newMongodbClient(ctx, config, nil)
+}
+
+func newMongodbClient(ctx context.Context, config *Config, monitor *event.PoolMonitor) (*mongo.Client, error) {
 	if len(config.Hosts) == 0 {
 		return nil, errors.New("Get replset client whitout hosts")
 	}
 
-	opts := options.Client().
-		SetHosts(config.Hosts).
-		SetReplicaSet(config.ReplSetName).
-		SetAuth(options.Credential{
-			Password: config.Password,
-			Username: config.Username,
-		}).
-		SetWriteConcern(writeconcern.New(writeconcern.WMajority(), writeconcern.J(true))).
-		SetReadPreference(readpref.Primary()).
-		SetDirect(config.Direct)
+	cred := credential(config, config.Password)
+	client, err := connect(ctx, config, &cred, monitor)
+	if err != nil || !config.InPasswordGrace(time.Now()) {
+		return client, err
+	}
+
+	err = client.Ping(ctx, readpref.Nearest())
+	var authErr *auth.Error
+	if err == nil || !errors.As(err, &authErr) {
+		return client, nil
+	}
+	_ = client.Disconnect(ctx)
+
+	cred = credential(config, config.PreviousPassword)
+	return connect(ctx, config, &cred, monitor)
+}
+
+// connect creates a client with the credential, an unauthenticated one if it
+// is nil.
+func connect(ctx context.Context, config *Config, cred *options.Credential, monitor *event.PoolMonitor) (*mongo.Client, error) {
+	opts, err := clientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	if cred != nil {
+		opts.SetAuth(*cred)
+	}
+	if monitor != nil {
+		opts.SetPoolMonitor(monitor)
+	}
 
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
@@ -56,8 +88,7 @@ func NewReplSetClient(ctx context.Context, hosts []string) (*mongo.Client, error
 	config := GetConfig().DeepCopy()
 	config.Hosts = hosts
 	config.Direct = false
-	return NewMongodbClient(ctx, config)
-
+	return clients.Get(ctx, config, true)
 }
 
 func NewMongosClient(ctx context.Context, hosts []string) (*mongo.Client, error) {
@@ -66,7 +97,7 @@ func NewMongosClient(ctx context.Context, hosts []string) (*mongo.Client, error)
 	config.Direct = false
 	config.ReplSetName = ""
 
-	return NewMongodbClient(ctx, config)
+	return clients.Get(ctx, config, true)
 }
 
 func NewStandaloneClient(ctx context.Context, host string) (*mongo.Client, error) {
@@ -75,7 +106,18 @@ func NewStandaloneClient(ctx context.Context, host string) (*mongo.Client, error
 	config.Direct = true
 	config.ReplSetName = ""
 
-	return NewMongodbClient(ctx, config)
+	return clients.Get(ctx, config, true)
+}
+
+// NewStandaloneUnauthClient connects to a single member without
+// authentication, e.g. an arbiter, which holds no users.
+func NewStandaloneUnauthClient(ctx context.Context, host string) (*mongo.Client, error) {
+	config := GetConfig().DeepCopy()
+	config.Hosts = []string{host}
+	config.Direct = true
+	config.ReplSetName = ""
+
+	return clients.Get(ctx, config, false)
 }
 
 func NewLocalUnauthClient(ctx context.Context) (*mongo.Client, error) {
@@ -83,16 +125,60 @@ func NewLocalUnauthClient(ctx context.Context) (*mongo.Client, error) {
 	config.Direct = true
 	config.ReplSetName = ""
 
+	return clients.Get(ctx, config, false)
+}
+
+// clientOptions returns the options shared by all clients, without
+// credentials.
+func clientOptions(config *Config) (*options.ClientOptions, error) {
 	opts := options.Client().
-		SetHosts(config.Hosts).
 		SetWriteConcern(writeconcern.New(writeconcern.WMajority(), writeconcern.J(true))).
-		SetReadPreference(readpref.Primary()).
+		SetReadPreference(readpref.Primary())
+	if config.OperationTimeout > 0 {
+		opts.SetTimeout(config.OperationTimeout)
+	}
+	if config.Params != "" {
+		opts.ApplyURI(paramsURI(config.Hosts, config.Params))
+		if err := opts.Validate(); err != nil {
+			return nil, errors.Wrap(err, "apply params")
+		}
+	}
+
+	opts.SetHosts(config.Hosts).
 		SetDirect(config.Direct)
+	if config.ReplSetName != "" {
+		opts.SetReplicaSet(config.ReplSetName)
+	}
 
-	client, err := mongo.Connect(ctx, opts)
+	tlsConfig, err := newTLSConfig(config)
 	if err != nil {
-		return nil, errors.Wrap(err, "connect to mongodb")
+		return nil, errors.Wrap(err, "load tls config")
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
 	}
+	return opts, nil
+}
 
-	return client, nil
+// paramsURI returns a connection string with the URI options, so that they
+// are parsed the way the driver parses them.
+func paramsURI(hosts []string, params string) string {
+	return "mongodb://" + strings.Join(hosts, ",") + "/?" + params
+}
+
+func credential(config *Config, password string) options.Credential {
+	if config.AuthMechanism == AuthMechanismX509 {
+		// the user is the subject of the client certificate
+		return options.Credential{
+			AuthMechanism: AuthMechanismX509,
+			AuthSource:    "$external",
+		}
+	}
+
+	return options.Credential{
+		AuthMechanism: config.AuthMechanism,
+		AuthSource:    config.AuthSource,
+		Username:      config.Username,
+		Password:      password,
+	}
 }
@@ -0,0 +1,334 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// clientIdleTimeout is how long a cached client is kept without being used.
+const clientIdleTimeout = 10 * time.Minute
+
+// clientCloseDelay is how long an invalidated client stays connected for the
+// operations still using it, as long as the longest operation may take.
+var clientCloseDelay = longestOperationTimeout()
+
+func longestOperationTimeout() time.Duration {
+	longest := time.Duration(0)
+	for _, timeout := range []time.Duration{resyncTimeout, shardRemovalTimeout, balancerIdleTimeout,
+		setFCVTimeout, reconfigCommitTimeout} {
+		if timeout > longest {
+			longest = timeout
+		}
+	}
+	return longest
+}
+
+// clientKey identifies the clients that can be shared: the same topology
+// reached with the same credentials and options.
+type clientKey struct {
+	hosts         string
+	replSetName   string
+	direct        bool
+	authenticated bool
+	username      string
+	credential    string
+	options       string
+}
+
+func newClientKey(config *Config, authenticated bool) clientKey {
+	hosts := append([]string{}, config.Hosts...)
+	sort.Strings(hosts)
+	key := clientKey{
+		hosts:         strings.Join(hosts, ","),
+		replSetName:   config.ReplSetName,
+		direct:        config.Direct,
+		authenticated: authenticated,
+		options: strings.Join([]string{config.Params, config.OperationTimeout.String(),
+			config.TLSCAFile, config.TLSCertFile, config.TLSKeyFile}, "|"),
+	}
+	if authenticated {
+		// keep a fingerprint rather than the passwords themselves
+		sum := sha256.Sum256([]byte(strings.Join([]string{config.AuthMechanism, config.AuthSource,
+			config.Password, config.PreviousPassword}, "|")))
+		key.username = config.Username
+		key.credential = hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// poolStats counts the connection pool events of a client.
+type poolStats struct {
+	open           atomic.Int64
+	inUse          atomic.Int64
+	created        atomic.Int64
+	closed         atomic.Int64
+	checkOutFailed atomic.Int64
+	// lastActive is when a connection was last checked out or returned, in
+	// unix nanoseconds.
+	lastActive atomic.Int64
+}
+
+func (s *poolStats) monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				s.created.Add(1)
+				s.open.Add(1)
+			case event.ConnectionClosed:
+				s.closed.Add(1)
+				s.open.Add(-1)
+			case event.GetSucceeded:
+				s.inUse.Add(1)
+				s.lastActive.Store(time.Now().UnixNano())
+			case event.ConnectionReturned:
+				s.inUse.Add(-1)
+				s.lastActive.Store(time.Now().UnixNano())
+			case event.GetFailed:
+				s.checkOutFailed.Add(1)
+			}
+		},
+	}
+}
+
+// pendingClient is a client being connected, the callers asking for it
+// meanwhile wait for done.
+type pendingClient struct {
+	done   chan struct{}
+	client *mongo.Client
+	err    error
+}
+
+type cachedClient struct {
+	key      clientKey
+	client   *mongo.Client
+	stats    *poolStats
+	lastUsed time.Time
+}
+
+// ClientStats are the connection pool statistics of a cached client.
+type ClientStats struct {
+	Hosts          []string
+	ReplSetName    string
+	Direct         bool
+	Authenticated  bool
+	Open           int64
+	InUse          int64
+	Created        int64
+	Closed         int64
+	CheckOutFailed int64
+	LastUsed       time.Time
+}
+
+// ClientRegistry shares clients, and their connection pools, between the
+// callers instead of connecting on every call. Clients are evicted when they
+// are idle, when a host leaves the replica set or when the credentials
+// change. It is safe for concurrent use, and the clients it returns MUST NOT
+// be disconnected by the callers.
+type ClientRegistry struct {
+	mu      sync.Mutex
+	clients map[clientKey]*cachedClient
+	pending map[clientKey]*pendingClient
+	// generation changes on every invalidation, a client connected across it
+	// may reach an invalidated host or use replaced credentials.
+	generation uint64
+	now        func() time.Time
+	connect    func(ctx context.Context, config *Config, authenticated bool, monitor *event.PoolMonitor) (*mongo.Client, error)
+}
+
+var clients = NewClientRegistry()
+
+func NewClientRegistry() *ClientRegistry {
+	return &ClientRegistry{
+		clients: map[clientKey]*cachedClient{},
+		pending: map[clientKey]*pendingClient{},
+		now:     time.Now,
+		connect: connectClient,
+	}
+}
+
+func connectClient(ctx context.Context, config *Config, authenticated bool, monitor *event.PoolMonitor) (*mongo.Client, error) {
+	if authenticated {
+		return newMongodbClient(ctx, config, monitor)
+	}
+	return connect(ctx, config, nil, monitor)
+}
+
+func GetClientRegistry() *ClientRegistry {
+	return clients
+}
+
+// Get returns the cached client of the config, connecting a new one if there
+// is none. The client is connected without holding the registry, callers
+// asking for the same client meanwhile wait for it.
+func (r *ClientRegistry) Get(ctx context.Context, config *Config, authenticated bool) (*mongo.Client, error) {
+	key := newClientKey(config, authenticated)
+
+	r.mu.Lock()
+	now := r.now()
+	r.evictLocked(func(c *cachedClient) bool {
+		return c.isIdle(now)
+	}, closeClient)
+
+	if cached, ok := r.clients[key]; ok {
+		cached.lastUsed = now
+		r.mu.Unlock()
+		return cached.client, nil
+	}
+	if pending, ok := r.pending[key]; ok {
+		r.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.client, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	pending := &pendingClient{done: make(chan struct{})}
+	r.pending[key] = pending
+	generation := r.generation
+	r.mu.Unlock()
+
+	stats := &poolStats{}
+	client, err := r.connect(ctx, config, authenticated, stats.monitor())
+
+	r.mu.Lock()
+	delete(r.pending, key)
+	if err == nil && generation == r.generation {
+		r.clients[key] = &cachedClient{
+			key:      key,
+			client:   client,
+			stats:    stats,
+			lastUsed: r.now(),
+		}
+	} else if err == nil {
+		// invalidated while connecting, the caller may still use it
+		retireClient(client)
+	}
+	r.mu.Unlock()
+
+	pending.client, pending.err = client, err
+	close(pending.done)
+	return client, err
+}
+
+// InvalidateHost evicts the clients that reach the host, after it left the
+// replica set.
+func (r *ClientRegistry) InvalidateHost(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.evictLocked(func(c *cachedClient) bool {
+		for _, h := range strings.Split(c.key.hosts, ",") {
+			if h == host {
+				return true
+			}
+		}
+		return false
+	}, retireClient)
+}
+
+// InvalidateAll evicts all the clients, after the credentials changed.
+func (r *ClientRegistry) InvalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.evictLocked(func(*cachedClient) bool {
+		return true
+	}, retireClient)
+}
+
+// Stats returns the connection pool statistics of the cached clients.
+func (r *ClientRegistry) Stats() []ClientStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]ClientStats, 0, len(r.clients))
+	for _, c := range r.clients {
+		stats = append(stats, ClientStats{
+			Hosts:          strings.Split(c.key.hosts, ","),
+			ReplSetName:    c.key.replSetName,
+			Direct:         c.key.direct,
+			Authenticated:  c.key.authenticated,
+			Open:           c.stats.open.Load(),
+			InUse:          c.stats.inUse.Load(),
+			Created:        c.stats.created.Load(),
+			Closed:         c.stats.closed.Load(),
+			CheckOutFailed: c.stats.checkOutFailed.Load(),
+			LastUsed:       c.lastUsed,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return strings.Join(stats[i].Hosts, ",") < strings.Join(stats[j].Hosts, ",")
+	})
+	return stats
+}
+
+// evictLocked removes the matching clients and disconnects them with
+// disconnect.
+func (r *ClientRegistry) evictLocked(match func(*cachedClient) bool, disconnect func(*mongo.Client)) {
+	for key, c := range r.clients {
+		if !match(c) {
+			continue
+		}
+		delete(r.clients, key)
+		disconnect(c.client)
+	}
+}
+
+// isIdle returns true if the client was neither handed out nor used for
+// clientIdleTimeout and has no connection checked out, no operation can be
+// using it.
+func (c *cachedClient) isIdle(now time.Time) bool {
+	lastUsed := c.lastUsed
+	if active := time.Unix(0, c.stats.lastActive.Load()); active.After(lastUsed) {
+		lastUsed = active
+	}
+	return now.Sub(lastUsed) > clientIdleTimeout && c.stats.inUse.Load() == 0
+}
+
+// closeClient disconnects an idle client right away.
+func closeClient(client *mongo.Client) {
+	go func() {
+		_ = client.Disconnect(context.Background())
+	}()
+}
+
+// retireClient disconnects a client that is no longer handed out once the
+// operations still using it had time to finish.
+func retireClient(client *mongo.Client) {
+	time.AfterFunc(clientCloseDelay, func() {
+		_ = client.Disconnect(context.Background())
+	})
+}
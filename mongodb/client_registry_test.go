@@ -0,0 +1,214 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestClientRegistry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	registry := NewClientRegistry()
+	registry.now = func() time.Time { return now }
+
+	config := &Config{
+		Hosts:       []string{"pod-1:27017", "pod-0:27017"},
+		ReplSetName: "rs0",
+		Username:    "root",
+		Password:    "secret",
+	}
+
+	t.Run("clients are shared", func(t *testing.T) {
+		client, err := registry.Get(ctx, config, true)
+		assert.Nil(t, err)
+
+		reordered := config.DeepCopy()
+		reordered.Hosts = []string{"pod-0:27017", "pod-1:27017"}
+		shared, err := registry.Get(ctx, reordered, true)
+		assert.Nil(t, err)
+		assert.Same(t, client, shared)
+
+		unauth, err := registry.Get(ctx, config, false)
+		assert.Nil(t, err)
+		assert.NotSame(t, client, unauth)
+
+		rotated := config.DeepCopy()
+		rotated.Password = "new-secret"
+		other, err := registry.Get(ctx, rotated, true)
+		assert.Nil(t, err)
+		assert.NotSame(t, client, other)
+		assert.Len(t, registry.Stats(), 3)
+	})
+
+	t.Run("concurrent callers get the same client", func(t *testing.T) {
+		standalone := config.DeepCopy()
+		standalone.Hosts = []string{"pod-2:27017"}
+		var wg sync.WaitGroup
+		got := make([]interface{}, 10)
+		for i := range got {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				got[i], _ = registry.Get(ctx, standalone, true)
+			}(i)
+		}
+		wg.Wait()
+		for i := range got {
+			assert.Same(t, got[0], got[i])
+		}
+	})
+
+	t.Run("invalidate host", func(t *testing.T) {
+		registry.InvalidateHost("pod-0:27017")
+		stats := registry.Stats()
+		assert.Len(t, stats, 1)
+		assert.Equal(t, []string{"pod-2:27017"}, stats[0].Hosts)
+	})
+
+	t.Run("clients in use are not idle", func(t *testing.T) {
+		registry.mu.Lock()
+		for _, c := range registry.clients {
+			c.stats.inUse.Add(1)
+		}
+		registry.mu.Unlock()
+		now = now.Add(clientIdleTimeout + time.Second)
+		_, err := registry.Get(ctx, config, true)
+		assert.Nil(t, err)
+		assert.Len(t, registry.Stats(), 2)
+
+		registry.mu.Lock()
+		for _, c := range registry.clients {
+			c.stats.inUse.Add(-1)
+		}
+		registry.mu.Unlock()
+	})
+
+	t.Run("idle clients are evicted", func(t *testing.T) {
+		now = now.Add(clientIdleTimeout + time.Second)
+		_, err := registry.Get(ctx, config, true)
+		assert.Nil(t, err)
+		stats := registry.Stats()
+		assert.Len(t, stats, 1)
+		assert.Equal(t, []string{"pod-0:27017", "pod-1:27017"}, stats[0].Hosts)
+	})
+
+	t.Run("invalidate all", func(t *testing.T) {
+		registry.InvalidateAll()
+		assert.Empty(t, registry.Stats())
+	})
+}
+
+func TestClientRegistryConnect(t *testing.T) {
+	ctx := context.Background()
+	registry := NewClientRegistry()
+	release := make(chan struct{})
+	var dials atomic.Int32
+	registry.connect = func(ctx context.Context, config *Config, authenticated bool, monitor *event.PoolMonitor) (*mongo.Client, error) {
+		if config.Hosts[0] == "slow:27017" {
+			dials.Add(1)
+			<-release
+		}
+		return connectClient(ctx, config, authenticated, monitor)
+	}
+	slow := &Config{Hosts: []string{"slow:27017"}, Direct: true}
+	fast := &Config{Hosts: []string{"fast:27017"}, Direct: true}
+	isPending := func() bool {
+		registry.mu.Lock()
+		defer registry.mu.Unlock()
+		return len(registry.pending) > 0
+	}
+
+	t.Run("clients are connected outside the lock", func(t *testing.T) {
+		got := make([]*mongo.Client, 2)
+		var wg sync.WaitGroup
+		for i := range got {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				got[i], _ = registry.Get(ctx, slow, false)
+			}(i)
+		}
+		assert.Eventually(t, isPending, time.Second, time.Millisecond)
+
+		_, err := registry.Get(ctx, fast, false)
+		assert.Nil(t, err)
+		assert.Len(t, registry.Stats(), 1)
+
+		release <- struct{}{}
+		wg.Wait()
+		assert.NotNil(t, got[0])
+		assert.Same(t, got[0], got[1])
+		assert.Equal(t, int32(1), dials.Load())
+		assert.Len(t, registry.Stats(), 2)
+	})
+
+	t.Run("clients invalidated while connecting are not cached", func(t *testing.T) {
+		registry.InvalidateAll()
+		done := make(chan *mongo.Client)
+		go func() {
+			client, _ := registry.Get(ctx, slow, false)
+			done <- client
+		}()
+		assert.Eventually(t, isPending, time.Second, time.Millisecond)
+
+		registry.InvalidateHost("slow:27017")
+		release <- struct{}{}
+		assert.NotNil(t, <-done)
+		assert.Empty(t, registry.Stats())
+	})
+
+	t.Run("waiting callers give up with their context", func(t *testing.T) {
+		go func() { _, _ = registry.Get(ctx, slow, false) }()
+		assert.Eventually(t, isPending, time.Second, time.Millisecond)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err := registry.Get(waitCtx, slow, false)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		release <- struct{}{}
+	})
+}
+
+func TestClientCloseDelay(t *testing.T) {
+	assert.Equal(t, shardRemovalTimeout, clientCloseDelay)
+}
+
+func TestPoolStats(t *testing.T) {
+	stats := &poolStats{}
+	monitor := stats.monitor()
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded,
+		event.GetSucceeded, event.ConnectionReturned, event.ConnectionClosed, event.GetFailed} {
+		monitor.Event(&event.PoolEvent{Type: typ})
+	}
+	assert.Equal(t, int64(1), stats.open.Load())
+	assert.Equal(t, int64(1), stats.inUse.Load())
+	assert.Equal(t, int64(2), stats.created.Load())
+	assert.Equal(t, int64(1), stats.closed.Load())
+	assert.Equal(t, int64(1), stats.checkOutFailed.Load())
+}
@@ -0,0 +1,57 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestClientOptions(t *testing.T) {
+	config := &Config{
+		Hosts:            []string{"pod-0:27017", "pod-1:27017"},
+		ReplSetName:      "rs0",
+		OperationTimeout: 5 * time.Second,
+	}
+
+	opts, err := clientOptions(config)
+	assert.Nil(t, err)
+	assert.Equal(t, readpref.PrimaryMode, opts.ReadPreference.Mode())
+	assert.Equal(t, 5*time.Second, *opts.Timeout)
+
+	config.Params = "readPreference=nearest&connectTimeoutMS=2000&compressors=zstd&appName=plugin&replicaSet=other&timeoutMS=1000"
+	opts, err = clientOptions(config)
+	assert.Nil(t, err)
+	assert.Equal(t, readpref.NearestMode, opts.ReadPreference.Mode())
+	assert.Equal(t, 2*time.Second, *opts.ConnectTimeout)
+	assert.Equal(t, []string{"zstd"}, opts.Compressors)
+	assert.Equal(t, "plugin", *opts.AppName)
+	assert.Equal(t, time.Second, *opts.Timeout)
+	// the topology always comes from the config
+	assert.Equal(t, "rs0", *opts.ReplicaSet)
+	assert.Equal(t, config.Hosts, opts.Hosts)
+
+	config.Params = "readPreference=unknown"
+	_, err = clientOptions(config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// Roles of a component in a sharded cluster. A plain replica set is the
+// default when no role is configured, marked or detected.
+const (
+	ComponentRoleReplSet      = "replicaset"
+	ComponentRoleConfigServer = "configsvr"
+	ComponentRoleShard        = "shardsvr"
+	ComponentRoleMongos       = "mongos"
+)
+
+// mongosMsg is the 'msg' field of the isMaster response of mongos.
+const mongosMsg = "isdbgrid"
+
+// GetComponentRole returns the role of the component. The configured role
+// wins, then the marker on the pod of the current member, and at last the
+// role the local server was started with, which is cached once detected.
+func (mgr *Manager) GetComponentRole(ctx context.Context, cluster *dcs.Cluster) (string, error) {
+	if role := GetConfig().ComponentRole; role != "" {
+		return role, nil
+	}
+
+	if cluster != nil {
+		if member := cluster.GetMemberWithName(mgr.CurrentMemberName); member != nil && member.ComponentRole != "" {
+			return member.ComponentRole, nil
+		}
+	}
+
+	if role := mgr.componentRole.Load(); role != nil {
+		return *role, nil
+	}
+
+	role, err := mgr.detectServerComponentRole(ctx)
+	if err != nil {
+		return "", err
+	}
+	mgr.componentRole.Store(&role)
+	return role, nil
+}
+
+// initiateComponentRole returns the role to initiate the replica set with.
+// Before initiation the server may not answer, or root not exist yet, so a
+// plain replica set is assumed if the role can not be detected.
+func (mgr *Manager) initiateComponentRole(ctx context.Context, cluster *dcs.Cluster) string {
+	role, err := mgr.GetComponentRole(ctx, cluster)
+	if err != nil {
+		mgr.Logger.Info("Detect component role failed, initiate a replica set", "error", err.Error())
+		return ComponentRoleReplSet
+	}
+	return role
+}
+
+// detectServerComponentRole asks the local server for its role. It tries
+// without authentication first, which works before root is created, and
+// with the root client once the server requires authentication.
+func (mgr *Manager) detectServerComponentRole(ctx context.Context) (string, error) {
+	client, err := NewLocalUnauthClient(ctx)
+	if err == nil {
+		var role string
+		if role, err = GetServerComponentRole(ctx, client); err == nil {
+			return role, nil
+		}
+	}
+	rootClient := mgr.GetClient()
+	if rootClient == nil {
+		return "", err
+	}
+	return GetServerComponentRole(ctx, rootClient)
+}
+
+// GetServerComponentRole detects the role the server was started with:
+// mongos answers isMaster with 'isdbgrid', config servers and shards have
+// the cluster role they were started with in their command line options.
+func GetServerComponentRole(ctx context.Context, client *mongo.Client) (string, error) {
+	isMaster, err := GetIsMaster(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	if isMaster.Msg == mongosMsg {
+		return ComponentRoleMongos, nil
+	}
+
+	opts, err := GetCmdLineOpts(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	return componentRoleFromClusterRole(opts.Parsed.Sharding.ClusterRole), nil
+}
+
+func componentRoleFromClusterRole(clusterRole string) string {
+	switch clusterRole {
+	case ComponentRoleConfigServer, ComponentRoleShard:
+		return clusterRole
+	default:
+		return ComponentRoleReplSet
+	}
+}
+
+// checkServerComponentRole returns an error if the local server was not
+// started with the cluster role the component is expected to have, e.g. a
+// shard started without --shardsvr.
+func (mgr *Manager) checkServerComponentRole(ctx context.Context, role string) error {
+	serverRole, err := mgr.detectServerComponentRole(ctx)
+	if err != nil {
+		return err
+	}
+	if serverRole != role {
+		return errors.Errorf("server is running as %s, but the component is %s", serverRole, role)
+	}
+	return nil
+}
+
+func GetCmdLineOpts(ctx context.Context, client *mongo.Client) (*CmdLineOpts, error) {
+	resp := &CmdLineOpts{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "getCmdLineOpts", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "getCmdLineOpts")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode getCmdLineOpts response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func TestComponentRoleFromClusterRole(t *testing.T) {
+	assert.Equal(t, ComponentRoleConfigServer, componentRoleFromClusterRole("configsvr"))
+	assert.Equal(t, ComponentRoleShard, componentRoleFromClusterRole("shardsvr"))
+	assert.Equal(t, ComponentRoleReplSet, componentRoleFromClusterRole(""))
+}
+
+func TestGetComponentRole(t *testing.T) {
+	old := GetConfig()
+	t.Cleanup(func() { currentConfig.Store(old) })
+	_, err := NewConfig(map[string]string{})
+	assert.Nil(t, err)
+	mgr := &Manager{CurrentMemberName: "pod-0"}
+	cluster := &dcs.Cluster{
+		Members: []dcs.Member{
+			{Name: "pod-0", ComponentRole: ComponentRoleShard},
+		},
+	}
+
+	t.Run("pod marker", func(t *testing.T) {
+		role, err := mgr.GetComponentRole(context.Background(), cluster)
+		assert.Nil(t, err)
+		assert.Equal(t, ComponentRoleShard, role)
+	})
+
+	t.Run("cached server role", func(t *testing.T) {
+		detected := ComponentRoleConfigServer
+		mgr.componentRole.Store(&detected)
+		role, err := mgr.GetComponentRole(context.Background(), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, ComponentRoleConfigServer, role)
+	})
+
+	t.Run("configured role", func(t *testing.T) {
+		_, err := NewConfig(map[string]string{"componentRole": ComponentRoleMongos})
+		assert.Nil(t, err)
+		role, err := mgr.GetComponentRole(context.Background(), cluster)
+		assert.Nil(t, err)
+		assert.Equal(t, ComponentRoleMongos, role)
+	})
+
+	t.Run("unsupported role", func(t *testing.T) {
+		_, err := NewConfig(map[string]string{"componentRole": "router"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestNewInitiateConfig(t *testing.T) {
+	mgr := &Manager{CurrentMemberName: "pod-0", ClusterCompName: "cfg"}
+	cluster := &dcs.Cluster{
+		Members: []dcs.Member{
+			{Name: "pod-0", PodIP: "10.0.0.1", DBPort: "27017", UseIP: true},
+			{Name: "pod-1", PodIP: "10.0.0.2", DBPort: "27017", UseIP: true},
+		},
+	}
+
+	config, err := mgr.newInitiateConfig(cluster, ComponentRoleConfigServer)
+	assert.Nil(t, err)
+	assert.Equal(t, "cfg", config.ID)
+	assert.True(t, config.Configsvr)
+	assert.Len(t, config.Members, 2)
+
+	config, err = mgr.newInitiateConfig(cluster, ComponentRoleShard)
+	assert.Nil(t, err)
+	assert.False(t, config.Configsvr)
+
+	cluster.Members[1].IsArbiter = true
+	_, err = mgr.newInitiateConfig(cluster, ComponentRoleConfigServer)
+	assert.NotNil(t, err)
+}
+
+func TestInitiateWithoutComponentRole(t *testing.T) {
+	old := GetConfig()
+	t.Cleanup(func() { currentConfig.Store(old) })
+	_, err := NewConfig(map[string]string{"host": "127.0.0.1:1"})
+	assert.Nil(t, err)
+
+	mgr := &Manager{CurrentMemberName: "pod-0", ClusterCompName: "rs"}
+	cluster := &dcs.Cluster{
+		Members: []dcs.Member{
+			{Name: "pod-0", PodIP: "10.0.0.1", DBPort: "27017", UseIP: true},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	role := mgr.initiateComponentRole(ctx, cluster)
+	assert.Equal(t, ComponentRoleReplSet, role)
+	assert.Nil(t, mgr.componentRole.Load())
+
+	config, err := mgr.newInitiateConfig(cluster, role)
+	assert.Nil(t, err)
+	assert.False(t, config.Configsvr)
+	assert.Len(t, config.Members, 1)
+}
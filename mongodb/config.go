@@ -23,9 +23,12 @@ import (
 	"errors"
 	"net"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/apecloud/mongodb_plugin/constant"
 )
@@ -40,32 +43,137 @@ const (
 	params           = "params"
 	adminDatabase    = "admin"
 
-	defaultTimeout = 5 * time.Second
-	defaultDBPort  = 27017
+	stepDownSecs               = "stepDownSecs"
+	secondaryCatchUpPeriodSecs = "secondaryCatchUpPeriodSecs"
+	livenessDialTimeout        = "livenessDialTimeout"
+	livenessHelloTimeout       = "livenessHelloTimeout"
+	livenessStuckThreshold     = "livenessStuckThreshold"
+	passwordGracePeriod        = "passwordGracePeriod"
+	roleTemplatesFile          = "roleTemplatesFile"
+	authMechanism              = "authMechanism"
+	authSource                 = "authSource"
+	tlsCAFile                  = "tlsCAFile"
+	tlsCertFile                = "tlsCertFile"
+	tlsKeyFile                 = "tlsKeyFile"
+	mongosHosts                = "mongosHosts"
+	componentRole              = "componentRole"
+	dataDir                    = "dataDir"
 
-	EnvRootUser     = "MONGODB_ROOT_USER"
-	EnvRootPassword = "MONGODB_ROOT_PASSWORD"
+	defaultTimeout                    = 5 * time.Second
+	defaultDBPort                     = 27017
+	defaultStepDownSecs               = 60
+	defaultSecondaryCatchUpPeriodSecs = 10
+	defaultLivenessDialTimeout        = time.Second
+	defaultLivenessHelloTimeout       = 2 * time.Second
+	defaultLivenessStuckThreshold     = time.Minute
+	defaultPasswordGracePeriod        = 5 * time.Minute
+	defaultDataDir                    = "/data/mongodb/db"
+
+	EnvRootUser                   = "MONGODB_ROOT_USER"
+	EnvRootPassword               = "MONGODB_ROOT_PASSWORD"
+	EnvStepDownSecs               = "MONGODB_STEP_DOWN_SECS"
+	EnvSecondaryCatchUpPeriodSecs = "MONGODB_SECONDARY_CATCH_UP_PERIOD_SECS"
+	EnvLivenessDialTimeout        = "MONGODB_LIVENESS_DIAL_TIMEOUT"
+	EnvLivenessHelloTimeout       = "MONGODB_LIVENESS_HELLO_TIMEOUT"
+	EnvLivenessStuckThreshold     = "MONGODB_LIVENESS_STUCK_THRESHOLD"
+	EnvPasswordGracePeriod        = "MONGODB_PASSWORD_GRACE_PERIOD"
+	EnvRoleTemplatesFile          = "MONGODB_ROLE_TEMPLATES_FILE"
+	EnvAuthMechanism              = "MONGODB_AUTH_MECHANISM"
+	EnvAuthSource                 = "MONGODB_AUTH_SOURCE"
+	EnvTLSCAFile                  = "MONGODB_TLS_CA_FILE"
+	EnvTLSCertFile                = "MONGODB_TLS_CERT_FILE"
+	EnvTLSKeyFile                 = "MONGODB_TLS_KEY_FILE"
+	EnvMongosHosts                = "MONGODB_MONGOS_HOSTS"
+	EnvComponentRole              = "MONGODB_COMPONENT_ROLE"
+	EnvDataDir                    = "MONGODB_DATA_DIR"
+
+	AuthMechanismX509        = "MONGODB-X509"
+	AuthMechanismSCRAMSHA1   = "SCRAM-SHA-1"
+	AuthMechanismSCRAMSHA256 = "SCRAM-SHA-256"
 )
 
 type Config struct {
-	Hosts            []string
-	Username         string
-	Password         string
-	ReplSetName      string
-	DatabaseName     string
-	Params           string
-	Direct           bool
+	Hosts        []string
+	Username     string
+	Password     string
+	ReplSetName  string
+	DatabaseName string
+	// Params are URI options, like readPreference=nearest&appName=plugin,
+	// applied to every client. Hosts, topology and credentials always come
+	// from the config.
+	Params string
+	Direct bool
+	// OperationTimeout bounds every command whose context has no deadline.
 	OperationTimeout time.Duration
+
+	// StepDownSecs is how long the old primary stays ineligible after a switchover.
+	StepDownSecs int
+	// SecondaryCatchUpPeriodSecs is how long the old primary waits for the
+	// candidate to catch up before stepping down.
+	SecondaryCatchUpPeriodSecs int
+
+	// LivenessDialTimeout bounds the TCP connect of the liveness check.
+	LivenessDialTimeout time.Duration
+	// LivenessHelloTimeout bounds the isMaster and currentOp commands of the
+	// liveness check.
+	LivenessHelloTimeout time.Duration
+	// LivenessStuckThreshold is how long an operation may wait for a lock
+	// before the server is considered stuck.
+	LivenessStuckThreshold time.Duration
+
+	// PasswordGracePeriod is how long the previous root password is still
+	// tried after a rotation.
+	PasswordGracePeriod time.Duration
+	// PreviousPassword is tried when Password is rejected, until
+	// PreviousPasswordExpiry.
+	PreviousPassword       string
+	PreviousPasswordExpiry time.Time
+
+	// RoleTemplatesFile holds the custom roles to reconcile, usually mounted
+	// from a ConfigMap. Roles are not reconciled if it is empty.
+	RoleTemplatesFile string
+
+	// AuthMechanism is negotiated with the server if empty. MONGODB-X509
+	// authenticates with the TLS client certificate instead of a password.
+	AuthMechanism string
+	// AuthSource is the database the user is defined in, admin by default and
+	// $external for x509.
+	AuthSource string
+	// TLS is used if any of the files is set. They are reloaded when they
+	// change, so that rotated certificates are picked up without a restart.
+	TLSCAFile   string
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// MongosHosts are the mongos routers of the sharded cluster the replica
+	// set is a shard of, it is not sharded if they are empty.
+	MongosHosts []string
+	// ComponentRole is the role of the component in a sharded cluster. It is
+	// detected from the pod marker or the server if empty.
+	ComponentRole string
+	// DataDir is the dbPath of the local mongod, wiped to resync the member.
+	DataDir string
 }
 
-var config *Config
+var currentConfig atomic.Pointer[Config]
 
 func NewConfig(properties map[string]string) (*Config, error) {
-	config = &Config{
+	config := &Config{
 		Hosts:            []string{"localhost:27017"},
 		Direct:           true,
 		Username:         "root",
 		OperationTimeout: defaultTimeout,
+
+		StepDownSecs:               defaultStepDownSecs,
+		SecondaryCatchUpPeriodSecs: defaultSecondaryCatchUpPeriodSecs,
+
+		LivenessDialTimeout:    defaultLivenessDialTimeout,
+		LivenessHelloTimeout:   defaultLivenessHelloTimeout,
+		LivenessStuckThreshold: defaultLivenessStuckThreshold,
+
+		PasswordGracePeriod: defaultPasswordGracePeriod,
+
+		DataDir: defaultDataDir,
 	}
 
 	if viper.IsSet("KB_SERVICE_PORT") {
@@ -106,7 +214,13 @@ func NewConfig(properties map[string]string) (*Config, error) {
 	}
 
 	if val, ok := properties[params]; ok && val != "" {
-		config.Params = val
+		config.Params = strings.TrimPrefix(val, "?")
+	}
+	if config.Params != "" {
+		err := options.Client().ApplyURI(paramsURI(config.Hosts, config.Params)).Validate()
+		if err != nil {
+			return nil, errors.New("incorrect params field from metadata: " + err.Error())
+		}
 	}
 
 	var err error
@@ -117,6 +231,103 @@ func NewConfig(properties map[string]string) (*Config, error) {
 		}
 	}
 
+	if val, ok := properties[stepDownSecs]; ok && val != "" {
+		config.StepDownSecs, err = strconv.Atoi(val)
+		if err != nil {
+			return nil, errors.New("incorrect stepDownSecs field from metadata")
+		}
+	}
+
+	if viper.IsSet(EnvStepDownSecs) {
+		config.StepDownSecs = viper.GetInt(EnvStepDownSecs)
+	}
+
+	if val, ok := properties[secondaryCatchUpPeriodSecs]; ok && val != "" {
+		config.SecondaryCatchUpPeriodSecs, err = strconv.Atoi(val)
+		if err != nil {
+			return nil, errors.New("incorrect secondaryCatchUpPeriodSecs field from metadata")
+		}
+	}
+
+	if viper.IsSet(EnvSecondaryCatchUpPeriodSecs) {
+		config.SecondaryCatchUpPeriodSecs = viper.GetInt(EnvSecondaryCatchUpPeriodSecs)
+	}
+
+	durations := []struct {
+		property string
+		env      string
+		value    *time.Duration
+	}{
+		{livenessDialTimeout, EnvLivenessDialTimeout, &config.LivenessDialTimeout},
+		{livenessHelloTimeout, EnvLivenessHelloTimeout, &config.LivenessHelloTimeout},
+		{livenessStuckThreshold, EnvLivenessStuckThreshold, &config.LivenessStuckThreshold},
+		{passwordGracePeriod, EnvPasswordGracePeriod, &config.PasswordGracePeriod},
+	}
+	for _, d := range durations {
+		if val, ok := properties[d.property]; ok && val != "" {
+			*d.value, err = time.ParseDuration(val)
+			if err != nil {
+				return nil, errors.New("incorrect " + d.property + " field from metadata")
+			}
+		}
+		if viper.IsSet(d.env) {
+			*d.value = viper.GetDuration(d.env)
+		}
+	}
+
+	settings := []struct {
+		property string
+		env      string
+		value    *string
+	}{
+		{roleTemplatesFile, EnvRoleTemplatesFile, &config.RoleTemplatesFile},
+		{authMechanism, EnvAuthMechanism, &config.AuthMechanism},
+		{authSource, EnvAuthSource, &config.AuthSource},
+		{tlsCAFile, EnvTLSCAFile, &config.TLSCAFile},
+		{tlsCertFile, EnvTLSCertFile, &config.TLSCertFile},
+		{tlsKeyFile, EnvTLSKeyFile, &config.TLSKeyFile},
+		{componentRole, EnvComponentRole, &config.ComponentRole},
+		{dataDir, EnvDataDir, &config.DataDir},
+	}
+	for _, s := range settings {
+		if val, ok := properties[s.property]; ok && val != "" {
+			*s.value = val
+		}
+		if viper.IsSet(s.env) {
+			*s.value = viper.GetString(s.env)
+		}
+	}
+
+	if val, ok := properties[mongosHosts]; ok && val != "" {
+		config.MongosHosts = strings.Split(val, ",")
+	}
+	if viper.IsSet(EnvMongosHosts) {
+		config.MongosHosts = strings.Split(viper.GetString(EnvMongosHosts), ",")
+	}
+
+	switch config.AuthMechanism {
+	case "", AuthMechanismSCRAMSHA1, AuthMechanismSCRAMSHA256:
+	case AuthMechanismX509:
+		if config.TLSCertFile == "" {
+			return nil, errors.New("MONGODB-X509 authentication needs a tls client certificate")
+		}
+	default:
+		return nil, errors.New("unsupported authMechanism " + config.AuthMechanism)
+	}
+	switch config.ComponentRole {
+	case "", ComponentRoleReplSet, ComponentRoleConfigServer, ComponentRoleShard, ComponentRoleMongos:
+	default:
+		return nil, errors.New("unsupported componentRole " + config.ComponentRole)
+	}
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, errors.New("tlsCertFile and tlsKeyFile must be set together")
+	}
+
+	if config.SecondaryCatchUpPeriodSecs >= config.StepDownSecs {
+		return nil, errors.New("secondaryCatchUpPeriodSecs must be less than stepDownSecs")
+	}
+
+	currentConfig.Store(config)
 	return config, nil
 }
 
@@ -138,9 +349,24 @@ func (config *Config) DeepCopy() *Config {
 	newConf := *config
 	newConf.Hosts = make([]string, len(config.Hosts))
 	copy(newConf.Hosts, config.Hosts)
+	newConf.MongosHosts = append([]string(nil), config.MongosHosts...)
 	return &newConf
 }
 
+// InPasswordGrace returns true if the previous password is still tried at
+// the given time.
+func (config *Config) InPasswordGrace(now time.Time) bool {
+	return config.PreviousPassword != "" && now.Before(config.PreviousPasswordExpiry)
+}
+
 func GetConfig() *Config {
-	return config
+	return currentConfig.Load()
+}
+
+// SwapConfig replaces the config in use and returns the replaced one. The
+// cached clients are evicted, since they may use the replaced credentials.
+func SwapConfig(config *Config) *Config {
+	old := currentConfig.Swap(config)
+	clients.InvalidateAll()
+	return old
 }
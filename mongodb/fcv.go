@@ -0,0 +1,344 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// setFCVTimeout bounds setFeatureCompatibilityVersion if the caller set no
+// deadline, it waits for the change to be replicated to every shard.
+const setFCVTimeout = 10 * time.Minute
+
+// upgradeReleases are the releases an upgrade goes through in turn, none of
+// them may be skipped.
+var upgradeReleases = []string{"3.6", "4.0", "4.2", "4.4", "5.0", "6.0", "7.0", "8.0"}
+
+// MemberVersion is the binary version and featureCompatibilityVersion of a
+// member. Arbiters and mongos hold no data, so they report no
+// featureCompatibilityVersion.
+type MemberVersion struct {
+	Name                        string
+	Host                        string
+	Version                     string
+	FeatureCompatibilityVersion string
+	// Message is why the versions of the member could not be read.
+	Message string
+}
+
+// GetMemberVersions asks every member of the cluster for its versions.
+func (mgr *Manager) GetMemberVersions(ctx context.Context, cluster *dcs.Cluster) []MemberVersion {
+	role, _ := mgr.GetComponentRole(ctx, cluster)
+	versions := make([]MemberVersion, 0, len(cluster.Members))
+	for _, member := range cluster.Members {
+		version := MemberVersion{
+			Name: member.Name,
+			Host: cluster.GetMemberAddrWithPort(member),
+		}
+		if err := getMemberVersion(ctx, &version, member.IsArbiter, role == ComponentRoleMongos); err != nil {
+			version.Message = err.Error()
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func getMemberVersion(ctx context.Context, version *MemberVersion, arbiter, mongos bool) error {
+	var client *mongo.Client
+	var err error
+	if arbiter {
+		client, err = NewStandaloneUnauthClient(ctx, version.Host)
+	} else {
+		client, err = NewStandaloneClient(ctx, version.Host)
+	}
+	if err != nil {
+		return err
+	}
+
+	buildInfo, err := GetBuildInfo(ctx, client)
+	if err != nil {
+		return err
+	}
+	version.Version = buildInfo.Version
+	if arbiter || mongos {
+		return nil
+	}
+
+	fcv, err := GetFCV(ctx, client)
+	if err != nil {
+		return err
+	}
+	version.FeatureCompatibilityVersion = fcv.FCV.Version
+	return nil
+}
+
+// GetFeatureCompatibilityVersion returns the featureCompatibilityVersion of
+// the cluster.
+func (mgr *Manager) GetFeatureCompatibilityVersion(ctx context.Context, cluster *dcs.Cluster) (string, error) {
+	fcv, err := mgr.getFCV(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+	return fcv.FCV.Version, nil
+}
+
+// CheckUpgrade returns an error if the binaries can not be upgraded to the
+// target version yet. Upgrading to a new major or minor release needs every
+// member to run the same release with its featureCompatibilityVersion set to
+// it, and the target must not skip the release after it. Patch releases can
+// always be installed.
+func (mgr *Manager) CheckUpgrade(ctx context.Context, cluster *dcs.Cluster, target string) error {
+	fcv, err := mgr.getFCV(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	return checkUpgrade(mgr.GetMemberVersions(ctx, cluster), fcv, target)
+}
+
+func checkUpgrade(versions []MemberVersion, fcv *FCV, target string) error {
+	targetVersion, err := featureVersion(target)
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if version.Message != "" {
+			return errors.Errorf("get versions of member %s failed: %s", version.Name, version.Message)
+		}
+		binaryVersion, err := featureVersion(version.Version)
+		if err != nil {
+			return err
+		}
+		if compareFeatureVersions(targetVersion, binaryVersion) <= 0 {
+			continue
+		}
+		if fcv.FCV.TargetVersion != "" {
+			return errors.Errorf("featureCompatibilityVersion is changing from %s to %s", fcv.FCV.Version, fcv.FCV.TargetVersion)
+		}
+		if fcv.FCV.Version != binaryVersion {
+			return errors.Errorf("featureCompatibilityVersion %s lags version %s of member %s, set it before upgrading to %s",
+				fcv.FCV.Version, version.Version, version.Name, target)
+		}
+	}
+
+	next := nextRelease(fcv.FCV.Version)
+	if compareFeatureVersions(targetVersion, next) > 0 {
+		return errors.Errorf("upgrading from featureCompatibilityVersion %s to %s skips release %s, upgrade to %s first",
+			fcv.FCV.Version, target, next, next)
+	}
+	return nil
+}
+
+// nextRelease returns the release to upgrade to from a major.minor release,
+// the next major release for the releases newer than the known ones.
+func nextRelease(version string) string {
+	for _, release := range upgradeReleases {
+		if compareFeatureVersions(release, version) > 0 {
+			return release
+		}
+	}
+	var major int
+	_, _ = fmt.Sscanf(version, "%d.", &major)
+	return fmt.Sprintf("%d.0", major+1)
+}
+
+// SetFeatureCompatibilityVersion sets the featureCompatibilityVersion of the
+// cluster, to the release of its binaries if version is empty, and returns
+// the replaced one. It is only raised once every member runs a release at
+// least as new, lowering it rolls an upgrade back as far as the server
+// supports it.
+func (mgr *Manager) SetFeatureCompatibilityVersion(ctx context.Context, cluster *dcs.Cluster, version string) (string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, setFCVTimeout)
+		defer cancel()
+	}
+
+	fcv, err := mgr.getFCV(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+
+	version, err = fcvToSet(mgr.GetMemberVersions(ctx, cluster), fcv, version)
+	if err != nil {
+		return "", err
+	}
+	if version == fcv.FCV.Version && fcv.FCV.TargetVersion == "" {
+		return fcv.FCV.Version, nil
+	}
+
+	client, err := mgr.getSetFCVClient(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+	buildInfo, err := GetBuildInfo(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	mgr.Logger.Info("set featureCompatibilityVersion", "from", fcv.FCV.Version, "to", version)
+	if err = SetFCV(ctx, client, version, buildInfo.AtLeast(7, 0)); err != nil {
+		return "", err
+	}
+	return fcv.FCV.Version, nil
+}
+
+// fcvToSet validates the featureCompatibilityVersion to set against the
+// releases the members run, the oldest of them if version is empty.
+func fcvToSet(versions []MemberVersion, fcv *FCV, version string) (string, error) {
+	oldest := ""
+	for _, member := range versions {
+		if member.Message != "" {
+			return "", errors.Errorf("get versions of member %s failed: %s", member.Name, member.Message)
+		}
+		binaryVersion, err := featureVersion(member.Version)
+		if err != nil {
+			return "", err
+		}
+		if oldest == "" || compareFeatureVersions(binaryVersion, oldest) < 0 {
+			oldest = binaryVersion
+		}
+	}
+	if oldest == "" {
+		return "", errors.New("cluster has no members")
+	}
+
+	if version == "" {
+		return oldest, nil
+	}
+	target, err := featureVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if target != version {
+		return "", errors.Errorf("featureCompatibilityVersion %s is not a major.minor release", version)
+	}
+	if compareFeatureVersions(target, fcv.FCV.Version) > 0 && compareFeatureVersions(target, oldest) > 0 {
+		return "", errors.Errorf("featureCompatibilityVersion %s is newer than release %s some members run", version, oldest)
+	}
+	return target, nil
+}
+
+// getFCV reads the featureCompatibilityVersion from the primary, mongos
+// has none.
+func (mgr *Manager) getFCV(ctx context.Context, cluster *dcs.Cluster) (*FCV, error) {
+	if role, _ := mgr.GetComponentRole(ctx, cluster); role == ComponentRoleMongos {
+		return nil, errors.New("mongos has no featureCompatibilityVersion")
+	}
+	client, err := mgr.GetLeaderClient(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return GetFCV(ctx, client)
+}
+
+// getSetFCVClient returns the client to set the featureCompatibilityVersion
+// with. Members of a sharded cluster must have it set through mongos.
+func (mgr *Manager) getSetFCVClient(ctx context.Context, cluster *dcs.Cluster) (*mongo.Client, error) {
+	role, _ := mgr.GetComponentRole(ctx, cluster)
+	if (role == ComponentRoleConfigServer || role == ComponentRoleShard) && len(GetConfig().MongosHosts) > 0 {
+		return mgr.GetMongosClient(ctx)
+	}
+	return mgr.GetLeaderClient(ctx, cluster)
+}
+
+// featureVersion returns the major.minor release of a version.
+func featureVersion(version string) (string, error) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return "", errors.Errorf("invalid version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", errors.Errorf("invalid version %q", version)
+	}
+	minor, err := strconv.Atoi(strings.SplitN(parts[1], "-", 2)[0])
+	if err != nil {
+		return "", errors.Errorf("invalid version %q", version)
+	}
+	return fmt.Sprintf("%d.%d", major, minor), nil
+}
+
+// compareFeatureVersions compares two major.minor releases.
+func compareFeatureVersions(a, b string) int {
+	var aMajor, aMinor, bMajor, bMinor int
+	_, _ = fmt.Sscanf(a, "%d.%d", &aMajor, &aMinor)
+	_, _ = fmt.Sscanf(b, "%d.%d", &bMajor, &bMinor)
+	if aMajor != bMajor {
+		return aMajor - bMajor
+	}
+	return aMinor - bMinor
+}
+
+func GetFCV(ctx context.Context, client *mongo.Client) (*FCV, error) {
+	resp := &FCV{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "getParameter", Value: 1},
+		{Key: "featureCompatibilityVersion", Value: 1},
+	})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "getParameter featureCompatibilityVersion")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode featureCompatibilityVersion")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+// SetFCV runs setFeatureCompatibilityVersion, which needs the confirmation
+// since 7.0.
+func SetFCV(ctx context.Context, client *mongo.Client, version string, confirm bool) error {
+	cmd := bson.D{{Key: "setFeatureCompatibilityVersion", Value: version}}
+	if confirm {
+		cmd = append(cmd, bson.E{Key: "confirm", Value: true})
+	}
+
+	resp := OKResponse{}
+	res := client.Database("admin").RunCommand(ctx, cmd)
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "setFeatureCompatibilityVersion")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode setFeatureCompatibilityVersion response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFCV(version, target string) *FCV {
+	fcv := &FCV{}
+	fcv.FCV.Version = version
+	fcv.FCV.TargetVersion = target
+	return fcv
+}
+
+func TestFeatureVersion(t *testing.T) {
+	version, err := featureVersion("7.0.12")
+	assert.Nil(t, err)
+	assert.Equal(t, "7.0", version)
+
+	version, err = featureVersion("8.0-rc1")
+	assert.Nil(t, err)
+	assert.Equal(t, "8.0", version)
+
+	_, err = featureVersion("7")
+	assert.NotNil(t, err)
+
+	assert.True(t, compareFeatureVersions("7.0", "6.0") > 0)
+	assert.True(t, compareFeatureVersions("4.4", "5.0") < 0)
+	assert.Equal(t, 0, compareFeatureVersions("6.0", "6.0"))
+}
+
+func TestCheckUpgrade(t *testing.T) {
+	versions := []MemberVersion{
+		{Name: "pod-0", Version: "6.0.14"},
+		{Name: "pod-1", Version: "6.0.14"},
+	}
+
+	assert.Nil(t, checkUpgrade(versions, newFCV("6.0", ""), "7.0.12"))
+	assert.Nil(t, checkUpgrade(versions, newFCV("5.0", ""), "6.0.15"))
+
+	err := checkUpgrade(versions, newFCV("5.0", ""), "7.0.12")
+	assert.ErrorContains(t, err, "lags")
+
+	err = checkUpgrade(versions, newFCV("5.0", "6.0"), "7.0.12")
+	assert.ErrorContains(t, err, "changing")
+
+	versions[1].Message = "connection refused"
+	assert.NotNil(t, checkUpgrade(versions, newFCV("6.0", ""), "7.0.12"))
+
+	versions = []MemberVersion{
+		{Name: "pod-0", Version: "4.4.29"},
+		{Name: "pod-1", Version: "4.4.29"},
+	}
+	assert.Nil(t, checkUpgrade(versions, newFCV("4.4", ""), "5.0.26"))
+	err = checkUpgrade(versions, newFCV("4.4", ""), "6.0.15")
+	assert.EqualError(t, err, "upgrading from featureCompatibilityVersion 4.4 to 6.0.15 skips release 5.0, upgrade to 5.0 first")
+}
+
+func TestNextRelease(t *testing.T) {
+	assert.Equal(t, "5.0", nextRelease("4.4"))
+	assert.Equal(t, "7.0", nextRelease("6.0"))
+	// rapid releases upgrade to the next major release
+	assert.Equal(t, "7.0", nextRelease("6.3"))
+	assert.Equal(t, "9.0", nextRelease("8.0"))
+}
+
+func TestFCVToSet(t *testing.T) {
+	versions := []MemberVersion{
+		{Name: "pod-0", Version: "7.0.12"},
+		{Name: "pod-1", Version: "6.0.14"},
+	}
+
+	version, err := fcvToSet(versions, newFCV("6.0", ""), "")
+	assert.Nil(t, err)
+	assert.Equal(t, "6.0", version)
+
+	_, err = fcvToSet(versions, newFCV("6.0", ""), "7.0")
+	assert.ErrorContains(t, err, "newer")
+
+	versions[1].Version = "7.0.12"
+	version, err = fcvToSet(versions, newFCV("6.0", ""), "7.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "7.0", version)
+
+	version, err = fcvToSet(versions, newFCV("7.0", ""), "6.0")
+	assert.Nil(t, err)
+	assert.Equal(t, "6.0", version)
+
+	_, err = fcvToSet(versions, newFCV("7.0", ""), "7.0.12")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// ForceReconfig rebuilds the replica set from the surviving members after a
+// majority was lost: every other member is removed from the config and the
+// config is installed on the current member with a forced reconfiguration.
+// The current member must be one of the survivors. It returns the installed
+// config and the removed hosts.
+func (mgr *Manager) ForceReconfig(ctx context.Context, cluster *dcs.Cluster, members []string) (*RSConfig, []string, error) {
+	survivors := map[string]bool{}
+	for _, name := range members {
+		if cluster.GetMemberWithName(name) == nil {
+			return nil, nil, errors.Errorf("member %s not found", name)
+		}
+		survivors[name] = true
+	}
+	if !survivors[mgr.CurrentMemberName] {
+		return nil, nil, errors.Errorf("current member %s is not one of the survivors", mgr.CurrentMemberName)
+	}
+
+	current := cluster.GetMemberWithName(mgr.CurrentMemberName)
+	client, err := NewStandaloneClient(ctx, cluster.GetMemberAddrWithPort(*current))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "connect to current member")
+	}
+
+	rsConfig, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get replSet config")
+	}
+
+	newConfig, removed, err := survivorConfig(rsConfig, len(survivors), func(host string) bool {
+		member := cluster.GetMemberWithHost(host)
+		return member != nil && survivors[member.Name]
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mgr.Logger.Info("force reconfig replset", "members", members, "removed", removed)
+	if err = ForceReplSetConfig(ctx, client, newConfig); err != nil {
+		return nil, nil, err
+	}
+	for _, host := range removed {
+		clients.InvalidateHost(host)
+	}
+
+	// the server raises the version of a forced config, report the real one
+	if installed, err := GetReplSetConfig(ctx, client); err == nil {
+		newConfig = installed
+	}
+	return newConfig, removed, nil
+}
+
+// survivorConfig returns a copy of the config with only the members whose
+// host isSurvivor accepts, which must be count members. Non-voters are
+// promoted to fill the free votes.
+func survivorConfig(rsConfig *RSConfig, count int, isSurvivor func(host string) bool) (*RSConfig, []string, error) {
+	newConfig := rsConfig.DeepCopy()
+	newConfig.Members = newConfig.Members[:0]
+	var removed []string
+	for _, m := range rsConfig.Members {
+		if isSurvivor(m.Host) {
+			newConfig.Members = append(newConfig.Members, m)
+		} else {
+			removed = append(removed, m.Host)
+		}
+	}
+	if len(newConfig.Members) != count {
+		return nil, nil, errors.Errorf("%d of the %d surviving members are in the replica set config", len(newConfig.Members), count)
+	}
+
+	promoteNonVoters(newConfig)
+
+	electable := false
+	for i := range newConfig.Members {
+		if newConfig.Members[i].ineligibleReason() == "" {
+			electable = true
+		}
+	}
+	if !electable {
+		return nil, nil, errors.New("no surviving member can become primary")
+	}
+
+	newConfig.Version++
+	return newConfig, removed, nil
+}
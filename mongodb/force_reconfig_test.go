@@ -0,0 +1,68 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurvivorConfig(t *testing.T) {
+	survivors := func(hosts ...string) func(string) bool {
+		return func(host string) bool {
+			for _, h := range hosts {
+				if h == host {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	t.Run("keeps the survivors and promotes non-voters", func(t *testing.T) {
+		rsConfig := mockRSConfig(7, 2)
+		rsConfig.Version = 10
+
+		newConfig, removed, err := survivorConfig(rsConfig, 3, survivors("pod-0:27017", "pod-1:27017", "pod-8:27017"))
+		assert.Nil(t, err)
+		assert.Equal(t, 11, newConfig.Version)
+		assert.Len(t, newConfig.Members, 3)
+		assert.Equal(t, 3, newConfig.VoterCount())
+		assert.Equal(t, float64(SecondaryPriority), newConfig.Members[2].Priority)
+		assert.Len(t, removed, 6)
+		assert.Len(t, rsConfig.Members, 9)
+	})
+
+	t.Run("survivor missing from config", func(t *testing.T) {
+		_, _, err := survivorConfig(mockRSConfig(3, 0), 2, survivors("pod-0:27017"))
+		assert.EqualError(t, err, "1 of the 2 surviving members are in the replica set config")
+	})
+
+	t.Run("no electable survivor", func(t *testing.T) {
+		arbiter := true
+		rsConfig := mockRSConfig(3, 0)
+		rsConfig.Members[2].ArbiterOnly = &arbiter
+		rsConfig.Members[2].Priority = 0
+
+		_, _, err := survivorConfig(rsConfig, 1, survivors("pod-2:27017"))
+		assert.EqualError(t, err, "no surviving member can become primary")
+	})
+}
@@ -21,10 +21,33 @@ package mongodb
 
 import (
 	"context"
+	"strings"
 
 	"github.com/apecloud/mongodb_plugin/dcs"
 )
 
+// GetReplicaRole returns the lower case replica set state of the current
+// member. Arbiters hold no users, so when the authenticated status check fails
+// the member is asked without authentication whether it is an arbiter. mongos
+// has no replica set state and reports its component role.
 func (mgr *Manager) GetReplicaRole(ctx context.Context, cluster *dcs.Cluster) (string, error) {
-	return mgr.GetMemberState(ctx)
+	if role, _ := mgr.GetComponentRole(ctx, cluster); role == ComponentRoleMongos {
+		return ComponentRoleMongos, nil
+	}
+
+	role, err := mgr.GetMemberState(ctx)
+	if err == nil {
+		return role, nil
+	}
+
+	client, cerr := NewLocalUnauthClient(ctx)
+	if cerr != nil {
+		return "", err
+	}
+
+	isArbiter, aerr := IsArbiter(ctx, client)
+	if aerr != nil || !isArbiter {
+		return "", err
+	}
+	return strings.ToLower(MemberStateStrings[MemberStateArbiter]), nil
 }
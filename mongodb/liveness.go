@@ -0,0 +1,141 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	LivenessAlive         = "alive"
+	LivenessNotListening  = "not-listening"
+	LivenessNotResponding = "not-responding"
+	LivenessStuck         = "stuck"
+)
+
+// Liveness is the result of a liveness check, Reason explains any status
+// other than alive.
+type Liveness struct {
+	Status string
+	Reason string
+}
+
+func (l *Liveness) IsAlive() bool {
+	return l.Status == LivenessAlive
+}
+
+// CheckLiveness tells a mongod that is not listening, one that is listening
+// but does not answer isMaster, and one that answers but is stuck behind a
+// long-held lock or an fsync lock that was not taken by this manager.
+func (mgr *Manager) CheckLiveness(ctx context.Context) *Liveness {
+	if liveness := mgr.CheckResponding(ctx); !liveness.IsAlive() {
+		return liveness
+	}
+
+	config := GetConfig()
+	opCtx, opCancel := context.WithTimeout(ctx, config.LivenessHelloTimeout)
+	defer opCancel()
+	resp, err := getLockWaitingOps(opCtx, mgr.GetClient(), int64(config.LivenessStuckThreshold.Seconds()))
+	if err != nil {
+		if mongo.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) {
+			return &Liveness{Status: LivenessStuck, Reason: "currentOp timed out: " + err.Error()}
+		}
+		// arbiters and members without users yet can not run currentOp,
+		// they are alive as long as they answer isMaster
+		mgr.Logger.Info("Get current ops failed", "error", err.Error())
+		return &Liveness{Status: LivenessAlive}
+	}
+
+	return evaluateCurrentOp(resp, mgr.IsLocked.Load())
+}
+
+// CheckResponding tells a mongod that is not listening and one that is
+// listening but does not answer isMaster. Unlike CheckLiveness, it does not
+// take a mongod that is busy behind a lock for a dead one.
+func (mgr *Manager) CheckResponding(ctx context.Context) *Liveness {
+	config := GetConfig()
+	host := config.Hosts[0]
+
+	conn, err := net.DialTimeout("tcp", host, config.LivenessDialTimeout)
+	if err != nil {
+		return &Liveness{Status: LivenessNotListening, Reason: err.Error()}
+	}
+	_ = conn.Close()
+
+	helloCtx, cancel := context.WithTimeout(ctx, config.LivenessHelloTimeout)
+	defer cancel()
+	client, err := NewLocalUnauthClient(helloCtx)
+	if err != nil {
+		return &Liveness{Status: LivenessNotResponding, Reason: err.Error()}
+	}
+
+	if _, err = IsArbiter(helloCtx, client); err != nil {
+		return &Liveness{Status: LivenessNotResponding, Reason: err.Error()}
+	}
+	return &Liveness{Status: LivenessAlive}
+}
+
+// getLockWaitingOps returns the operations that have been waiting for a lock
+// for at least secs seconds.
+func getLockWaitingOps(ctx context.Context, client *mongo.Client, secs int64) (*CurrentOpResp, error) {
+	resp := &CurrentOpResp{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "currentOp", Value: 1},
+		{Key: "waitingForLock", Value: true},
+		{Key: "secs_running", Value: bson.M{"$gte": secs}},
+	})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "currentOp")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode currentOp response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+// evaluateCurrentOp decides whether the server is stuck from the operations
+// waiting for a lock. An fsync lock held by the manager is expected.
+func evaluateCurrentOp(resp *CurrentOpResp, locked bool) *Liveness {
+	if resp.FsyncLock && !locked {
+		return &Liveness{Status: LivenessStuck, Reason: "fsync lock left over"}
+	}
+
+	if len(resp.Inprog) == 0 || locked {
+		return &Liveness{Status: LivenessAlive}
+	}
+
+	op := resp.Inprog[0]
+	reason := fmt.Sprintf("%d operations waiting for a lock, %s on %s for %ds",
+		len(resp.Inprog), op.Op, op.Ns, op.SecsRunning)
+	return &Liveness{Status: LivenessStuck, Reason: reason}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCurrentOp(t *testing.T) {
+	waiting := []CurrentOp{{Op: "insert", Ns: "test.c", SecsRunning: 90, WaitingForLock: true}}
+
+	t.Run("no waiting ops", func(t *testing.T) {
+		liveness := evaluateCurrentOp(&CurrentOpResp{}, false)
+		assert.True(t, liveness.IsAlive())
+	})
+
+	t.Run("ops waiting for a lock", func(t *testing.T) {
+		liveness := evaluateCurrentOp(&CurrentOpResp{Inprog: waiting}, false)
+		assert.Equal(t, LivenessStuck, liveness.Status)
+		assert.Contains(t, liveness.Reason, "insert on test.c for 90s")
+	})
+
+	t.Run("fsync lock left over", func(t *testing.T) {
+		liveness := evaluateCurrentOp(&CurrentOpResp{FsyncLock: true}, false)
+		assert.Equal(t, LivenessStuck, liveness.Status)
+		assert.Equal(t, "fsync lock left over", liveness.Reason)
+	})
+
+	t.Run("fsync locked by the manager", func(t *testing.T) {
+		liveness := evaluateCurrentOp(&CurrentOpResp{FsyncLock: true, Inprog: waiting}, true)
+		assert.True(t, liveness.IsAlive())
+	})
+}
+
+func TestLivenessWiring(t *testing.T) {
+	old := GetConfig()
+	t.Cleanup(func() { currentConfig.Store(old) })
+	config, err := NewConfig(map[string]string{})
+	assert.Nil(t, err)
+	config.Hosts = []string{"127.0.0.1:1"}
+
+	mgr := &Manager{}
+	assert.Equal(t, LivenessNotListening, mgr.CheckResponding(context.Background()).Status)
+	assert.Equal(t, LivenessNotListening, mgr.CheckLiveness(context.Background()).Status)
+	assert.False(t, mgr.IsRunning())
+	assert.False(t, mgr.IsResponding())
+}
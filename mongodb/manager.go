@@ -23,8 +23,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -32,9 +32,7 @@ import (
 	"github.com/spf13/viper"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
-	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/apecloud/mongodb_plugin/constant"
@@ -49,8 +47,9 @@ const (
 )
 
 type Manager struct {
-	Client   *mongo.Client
-	Database *mongo.Database
+	// client is the root client of the local member, it is replaced when
+	// the root password is rotated.
+	client atomic.Pointer[mongo.Client]
 
 	CurrentMemberName string
 	CurrentMemberIP   string
@@ -59,8 +58,11 @@ type Manager struct {
 	DataDir           string
 	Logger            logr.Logger
 	DBStartupReady    bool
-	IsLocked          bool
+	IsLocked          atomic.Bool
 	DBState           *dcs.DBState
+
+	// componentRole caches the role detected from the local server.
+	componentRole atomic.Pointer[string]
 }
 
 var Mgr *Manager
@@ -78,20 +80,9 @@ func NewManager(properties map[string]string) (*Manager, error) {
 		return nil, err
 	}
 
-	opts := options.Client().
-		SetHosts(config.Hosts).
-		SetReplicaSet(config.ReplSetName).
-		SetAuth(options.Credential{
-			Password: config.Password,
-			Username: config.Username,
-		}).
-		SetWriteConcern(writeconcern.New(writeconcern.WMajority(), writeconcern.J(true))).
-		SetReadPreference(readpref.Primary()).
-		SetDirect(config.Direct)
-
-	client, err := mongo.Connect(ctx, opts)
+	client, err := NewMongodbClient(ctx, config)
 	if err != nil {
-		return nil, errors.Wrap(err, "connect to mongodb")
+		return nil, err
 	}
 
 	defer func() {
@@ -104,50 +95,50 @@ func NewManager(properties map[string]string) (*Manager, error) {
 	}()
 
 	Mgr = &Manager{
-		Client:            client,
-		Database:          client.Database(config.DatabaseName),
 		CurrentMemberName: currentMemberName,
 		CurrentMemberIP:   viper.GetString(constant.KBEnvPodIP),
 		ClusterCompName:   viper.GetString(constant.KBEnvClusterCompName),
 		Namespace:         viper.GetString(constant.KBEnvNamespace),
+		DataDir:           config.DataDir,
 		Logger:            logger,
 	}
+	Mgr.client.Store(client)
 
 	return Mgr, nil
 }
 
+// GetClient returns the root client of the local member. It MUST NOT be
+// disconnected by the callers.
+func (mgr *Manager) GetClient() *mongo.Client {
+	return mgr.client.Load()
+}
+
 func (mgr *Manager) IsFirstMember(*dcs.Cluster) bool {
 	return strings.HasSuffix(mgr.CurrentMemberName, "-0")
 }
 
+// InitializeCluster initiates the replica set of the component. mongos has
+// no replica set, so there is nothing to initialize.
 func (mgr *Manager) InitializeCluster(ctx context.Context, cluster *dcs.Cluster) error {
+	if mgr.initiateComponentRole(ctx, cluster) == ComponentRoleMongos {
+		return nil
+	}
 	return mgr.InitiateReplSet(ctx, cluster)
 }
 
 // InitiateReplSet is a method to create MongoDB cluster
 func (mgr *Manager) InitiateReplSet(ctx context.Context, cluster *dcs.Cluster) error {
-	configMembers := make([]ConfigMember, len(cluster.Members))
-
-	for i, member := range cluster.Members {
-		configMembers[i].ID = i
-		configMembers[i].Host = cluster.GetMemberAddrWithPort(member)
-		if strings.HasPrefix(member.Name, mgr.CurrentMemberName) || strings.HasPrefix(member.Name, mgr.CurrentMemberIP) {
-			configMembers[i].Priority = PrimaryPriority
-		} else {
-			configMembers[i].Priority = SecondaryPriority
-		}
+	role := mgr.initiateComponentRole(ctx, cluster)
+	config, err := mgr.newInitiateConfig(cluster, role)
+	if err != nil {
+		return err
 	}
 
-	config := RSConfig{
-		ID:      mgr.ClusterCompName,
-		Members: configMembers,
-	}
 	client, err := NewLocalUnauthClient(ctx)
 	if err != nil {
 		mgr.Logger.Info("Get local unauth client failed", "error", err.Error())
 		return err
 	}
-	defer client.Disconnect(context.TODO()) //nolint:errcheck
 
 	configJSON, _ := json.Marshal(config)
 	mgr.Logger.Info(fmt.Sprintf("Initial Replset Config: %s", string(configJSON)))
@@ -158,14 +149,53 @@ func (mgr *Manager) InitiateReplSet(ctx context.Context, cluster *dcs.Cluster) e
 	return nil
 }
 
+// newInitiateConfig builds the replica set config to initiate with the
+// members of the cluster. Config servers are flagged as such and can not
+// have arbiters.
+func (mgr *Manager) newInitiateConfig(cluster *dcs.Cluster, role string) (*RSConfig, error) {
+	configMembers := make([]ConfigMember, len(cluster.Members))
+
+	for i, member := range cluster.Members {
+		configMembers[i].ID = i
+		configMembers[i].Host = cluster.GetMemberAddrWithPort(member)
+		if member.IsArbiter {
+			arbiterOnly := true
+			configMembers[i].ArbiterOnly = &arbiterOnly
+			configMembers[i].Priority = 0
+		} else if strings.HasPrefix(member.Name, mgr.CurrentMemberName) || strings.HasPrefix(member.Name, mgr.CurrentMemberIP) {
+			configMembers[i].Priority = PrimaryPriority
+		} else {
+			configMembers[i].Priority = SecondaryPriority
+		}
+	}
+
+	config := &RSConfig{
+		ID:        mgr.ClusterCompName,
+		Configsvr: role == ComponentRoleConfigServer,
+	}
+	for i := range configMembers {
+		if config.Configsvr && configMembers[i].ArbiterOnly != nil {
+			return nil, errors.Errorf("config server replica set can not have arbiter %s", configMembers[i].Host)
+		}
+		if err := setJoinVotes(config, &configMembers[i]); err != nil {
+			return nil, err
+		}
+		config.Members = append(config.Members, configMembers[i])
+	}
+	return config, nil
+}
+
 // IsClusterInitialized is a method to check if cluster is initialized or not
 func (mgr *Manager) IsClusterInitialized(ctx context.Context, cluster *dcs.Cluster) (bool, error) {
+	if role, _ := mgr.GetComponentRole(ctx, cluster); role == ComponentRoleMongos {
+		return true, nil
+	}
+
 	client, err := mgr.GetReplSetClient(ctx, cluster)
 	if err != nil {
 		mgr.Logger.Info("Get leader client failed", "error", err.Error())
 		return false, err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	ctx1, cancel := context.WithTimeout(ctx, 1000*time.Millisecond)
 	defer cancel()
@@ -184,7 +214,6 @@ func (mgr *Manager) IsClusterInitialized(ctx context.Context, cluster *dcs.Clust
 		mgr.Logger.Info("Get local unauth client failed", "error", err.Error())
 		return false, err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	rsStatus, err = GetReplSetStatus(ctx, client)
 	if rsStatus != nil {
@@ -219,7 +248,6 @@ func (mgr *Manager) IsRootCreated(ctx context.Context) (bool, error) {
 		mgr.Logger.Info("Get local unauth client failed", "error", err.Error())
 		return false, err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	_, err = GetReplSetStatus(ctx, client)
 	if err == nil {
@@ -252,14 +280,14 @@ func (mgr *Manager) CreateRoot(ctx context.Context) error {
 		mgr.Logger.Info("Get local unauth client failed", "error", err.Error())
 		return err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	role := map[string]interface{}{
 		"role": "root",
 		"db":   "admin",
 	}
 
-	mgr.Logger.Info(fmt.Sprintf("Create user: %s, passwd: %s, roles: %v", config.Username, config.Password, role))
+	config := GetConfig()
+	mgr.Logger.Info(fmt.Sprintf("Create user: %s, roles: %v", config.Username, role))
 	err = CreateUser(ctx, client, config.Username, config.Password, role)
 	if err != nil {
 		mgr.Logger.Info("Create Root failed", "error", err.Error())
@@ -269,15 +297,26 @@ func (mgr *Manager) CreateRoot(ctx context.Context) error {
 	return nil
 }
 
+// IsRunning returns true if mongod is alive, it is the liveness check and
+// fails for a mongod stuck behind a lock. The reconciler skips its rounds
+// while it is false.
 func (mgr *Manager) IsRunning() bool {
-	// ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
-	// defer cancel()
-
-	// err := mgr.Client.Ping(ctx, readpref.Nearest())
-	// if err != nil {
-	// 	mgr.Logger.Infof("DB is not ready: %v", err)
-	// 	return false
-	// }
+	liveness := mgr.CheckLiveness(context.Background())
+	if !liveness.IsAlive() {
+		mgr.Logger.Info("DB is not alive", "status", liveness.Status, "reason", liveness.Reason)
+		return false
+	}
+	return true
+}
+
+// IsResponding returns true if mongod answers isMaster. It is the check of
+// readiness, which must not fail for a mongod that is only busy.
+func (mgr *Manager) IsResponding() bool {
+	liveness := mgr.CheckResponding(context.Background())
+	if !liveness.IsAlive() {
+		mgr.Logger.Info("DB is not responding", "status", liveness.Status, "reason", liveness.Reason)
+		return false
+	}
 	return true
 }
 
@@ -288,7 +327,7 @@ func (mgr *Manager) IsDBStartupReady() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
-	err := mgr.Client.Ping(ctx, readpref.Primary())
+	err := mgr.GetClient().Ping(ctx, readpref.Primary())
 	if err != nil {
 		mgr.Logger.Info("DB is not ready", "error", err.Error())
 		return false
@@ -313,7 +352,11 @@ func (mgr *Manager) GetMemberState(ctx context.Context) (string, error) {
 }
 
 func (mgr *Manager) GetReplSetStatus(ctx context.Context) (*ReplSetStatus, error) {
-	return GetReplSetStatus(ctx, mgr.Client)
+	return GetReplSetStatus(ctx, mgr.GetClient())
+}
+
+func (mgr *Manager) GetElectionMetrics(ctx context.Context) (*ElectionMetrics, error) {
+	return GetElectionMetrics(ctx, mgr.GetClient())
 }
 
 func (mgr *Manager) IsLeaderMember(ctx context.Context, cluster *dcs.Cluster, dcsMember *dcs.Member) (bool, error) {
@@ -334,7 +377,7 @@ func (mgr *Manager) IsLeaderMember(ctx context.Context, cluster *dcs.Cluster, dc
 }
 
 func (mgr *Manager) IsLeader(ctx context.Context, cluster *dcs.Cluster) (bool, error) {
-	cur := mgr.Client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}})
+	cur := mgr.GetClient().Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}})
 	if cur.Err() != nil {
 		return false, errors.Wrap(cur.Err(), "run isMaster")
 	}
@@ -352,7 +395,7 @@ func (mgr *Manager) IsLeader(ctx context.Context, cluster *dcs.Cluster) (bool, e
 }
 
 func (mgr *Manager) GetReplSetConfig(ctx context.Context) (*RSConfig, error) {
-	return GetReplSetConfig(ctx, mgr.Client)
+	return GetReplSetConfig(ctx, mgr.GetClient())
 }
 
 func (mgr *Manager) GetMemberAddrs(ctx context.Context, cluster *dcs.Cluster) []string {
@@ -361,7 +404,6 @@ func (mgr *Manager) GetMemberAddrs(ctx context.Context, cluster *dcs.Cluster) []
 		mgr.Logger.Info("Get replSet client failed", "error", err.Error())
 		return nil
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	rsConfig, err := GetReplSetConfig(ctx, client)
 	if rsConfig == nil {
@@ -406,22 +448,7 @@ func (mgr *Manager) GetReplSetClientWithHosts(ctx context.Context, hosts []strin
 		return nil, err
 	}
 
-	opts := options.Client().
-		SetHosts(hosts).
-		SetReplicaSet(config.ReplSetName).
-		SetAuth(options.Credential{
-			Password: config.Password,
-			Username: config.Username,
-		}).
-		SetWriteConcern(writeconcern.New(writeconcern.WMajority(), writeconcern.J(true))).
-		SetReadPreference(readpref.Primary()).
-		SetDirect(false)
-
-	client, err := mongo.Connect(ctx, opts)
-	if err != nil {
-		return nil, errors.Wrap(err, "connect to mongodb")
-	}
-	return client, err
+	return NewReplSetClient(ctx, hosts)
 }
 
 func (mgr *Manager) IsCurrentMemberInCluster(ctx context.Context, cluster *dcs.Cluster) bool {
@@ -430,7 +457,6 @@ func (mgr *Manager) IsCurrentMemberInCluster(ctx context.Context, cluster *dcs.C
 		mgr.Logger.Info("Get replSet client failed", "error", err.Error())
 		return true
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	rsConfig, err := GetReplSetConfig(ctx, client)
 	if rsConfig == nil {
@@ -491,7 +517,6 @@ func (mgr *Manager) UpdateCurrentMemberHost(ctx context.Context, cluster *dcs.Cl
 	if err != nil {
 		return err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	currentMember := cluster.GetMemberWithName(mgr.CurrentMemberName)
 	currentHost := cluster.GetMemberAddrWithPort(*currentMember)
@@ -524,23 +549,30 @@ func (mgr *Manager) UpdateCurrentMemberHost(ctx context.Context, cluster *dcs.Cl
 	configMember := invalidMembers[0]
 	configMember.Host = currentHost
 
-	rsConfig.Version++
-	return SetReplSetConfig(ctx, client, rsConfig)
+	return mgr.Reconfigure(ctx, client, rsConfig)
 }
 
 func (mgr *Manager) JoinCurrentMemberToCluster(ctx context.Context, cluster *dcs.Cluster) error {
 	currentMemberName := mgr.CurrentMemberName
-	return mgr.JoinMemberToCluster(ctx, cluster, currentMemberName)
+	return mgr.JoinMemberToCluster(ctx, cluster, currentMemberName, false)
 }
 
-func (mgr *Manager) JoinMemberToCluster(ctx context.Context, cluster *dcs.Cluster, memberName string) error {
+// JoinMemberToCluster adds the member to the replica set as a secondary, or
+// as an arbiter with priority 0 if arbiter is set or the member's pod is
+// marked as an arbiter. Members beyond the seventh voter join as non-voters.
+// Joining a member already in the config is a no-op.
+func (mgr *Manager) JoinMemberToCluster(ctx context.Context, cluster *dcs.Cluster, memberName string, arbiter bool) error {
+	joinMember := cluster.GetMemberWithName(memberName)
+	if joinMember == nil {
+		return errors.Errorf("member %s not found", memberName)
+	}
+	arbiter = arbiter || joinMember.IsArbiter
+
 	client, err := mgr.GetReplSetClient(ctx, cluster)
 	if err != nil {
 		return err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
-	joinMember := cluster.GetMemberWithName(memberName)
 	joinHost := cluster.GetMemberAddrWithPort(*joinMember)
 	rsConfig, err := GetReplSetConfig(ctx, client)
 	if rsConfig == nil {
@@ -548,20 +580,53 @@ func (mgr *Manager) JoinMemberToCluster(ctx context.Context, cluster *dcs.Cluste
 		return err
 	}
 
+	for _, configMember := range rsConfig.Members {
+		if configMember.Host == joinHost {
+			mgr.Logger.Info("member is already in the replica set", "member", memberName)
+			return nil
+		}
+	}
+	configMember := newJoinConfigMember(rsConfig, joinHost, arbiter)
+	if arbiter {
+		if err = PinDefaultWriteConcern(ctx, client); err != nil {
+			return errors.Wrap(err, "pin default write concern")
+		}
+	} else if joinMember.Hidden || joinMember.DelaySecs > 0 {
+		buildInfo, err := GetBuildInfo(ctx, client)
+		if err != nil {
+			return errors.Wrap(err, "get build info")
+		}
+		applyMemberAttributes(&configMember, joinMember, buildInfo.AtLeast(5, 0))
+	}
+	if err = setJoinVotes(rsConfig, &configMember); err != nil {
+		return err
+	}
+	mgr.Logger.Info("Join member", "member", memberName, "arbiter", arbiter, "voter", configMember.IsVoter())
+	rsConfig.Members = append(rsConfig.Members, configMember)
+
+	return mgr.Reconfigure(ctx, client, rsConfig)
+}
+
+// newJoinConfigMember returns the config of a member joining the replica set
+// with the next free id. Arbiters hold no data and are never elected.
+func newJoinConfigMember(rsConfig *RSConfig, host string, arbiter bool) ConfigMember {
 	var lastID int
-	var configMember ConfigMember
-	for _, configMember = range rsConfig.Members {
+	for _, configMember := range rsConfig.Members {
 		if configMember.ID > lastID {
 			lastID = configMember.ID
 		}
 	}
-	configMember.ID = lastID + 1
-	configMember.Host = joinHost
-	configMember.Priority = SecondaryPriority
-	rsConfig.Members = append(rsConfig.Members, configMember)
-
-	rsConfig.Version++
-	return SetReplSetConfig(ctx, client, rsConfig)
+	configMember := ConfigMember{
+		ID:       lastID + 1,
+		Host:     host,
+		Priority: SecondaryPriority,
+	}
+	if arbiter {
+		arbiterOnly := true
+		configMember.ArbiterOnly = &arbiterOnly
+		configMember.Priority = 0
+	}
+	return configMember
 }
 
 func (mgr *Manager) LeaveMemberFromCluster(ctx context.Context, cluster *dcs.Cluster, memberName string) error {
@@ -569,7 +634,6 @@ func (mgr *Manager) LeaveMemberFromCluster(ctx context.Context, cluster *dcs.Clu
 	if err != nil {
 		return err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	rsConfig, err := GetReplSetConfig(ctx, client)
 	if rsConfig == nil {
@@ -586,11 +650,17 @@ func (mgr *Manager) LeaveMemberFromCluster(ctx context.Context, cluster *dcs.Clu
 	}
 
 	isDeleted := true
+	isArbiter := false
+	isVoter := false
+	leavingHost := ""
 	mgr.Logger.Info("leave", "member", memberName, "ip", mgr.CurrentMemberIP)
 	for _, configMember := range rsConfig.Members {
 		if strings.HasPrefix(configMember.Host, memberName) ||
 			(memberIP != "" && strings.HasPrefix(configMember.Host, memberIP)) {
 			isDeleted = false
+			isArbiter = configMember.ArbiterOnly != nil && *configMember.ArbiterOnly
+			isVoter = configMember.IsVoter()
+			leavingHost = configMember.Host
 			continue
 		}
 		configMembers = append(configMembers, configMember)
@@ -600,18 +670,61 @@ func (mgr *Manager) LeaveMemberFromCluster(ctx context.Context, cluster *dcs.Clu
 		return nil
 	}
 
+	if isArbiter {
+		if err = PinDefaultWriteConcern(ctx, client); err != nil {
+			return errors.Wrap(err, "pin default write concern")
+		}
+	}
+
 	rsConfig.Members = configMembers
-	rsConfig.Version++
-	return SetReplSetConfig(ctx, client, rsConfig)
+	if promoted := promoteNonVoter(rsConfig); promoted != nil {
+		mgr.Logger.Info("promote non-voting member", "member", promoted.Host)
+	}
+	// without a non-voter to promote, a voter is demoted to keep the number
+	// of voters odd
+	if isVoter && rsConfig.VoterCount()%2 == 0 {
+		rsStatus, err := GetReplSetStatus(ctx, client)
+		if err != nil {
+			return errors.Wrap(err, "get replSet status")
+		}
+		primary := ""
+		if p := rsStatus.Primary(); p != nil {
+			primary = p.Name
+		}
+		if demoted := demoteVoter(rsConfig, primary); demoted != nil {
+			mgr.Logger.Info("demote voting member", "member", demoted.Host)
+		}
+	}
+	if err = mgr.Reconfigure(ctx, client, rsConfig); err != nil {
+		return err
+	}
+	clients.InvalidateHost(leavingHost)
+	return nil
 }
 
+// IsClusterHealthy checks the replica set status. mongos is healthy if it
+// can list the shards, and members of a sharded cluster must run with the
+// cluster role of their component.
 func (mgr *Manager) IsClusterHealthy(ctx context.Context, cluster *dcs.Cluster) bool {
+	role, err := mgr.GetComponentRole(ctx, cluster)
+	if err != nil {
+		mgr.Logger.Info("Get component role failed", "error", err.Error())
+	}
+	switch role {
+	case ComponentRoleMongos:
+		return mgr.isMongosHealthy(ctx)
+	case ComponentRoleConfigServer, ComponentRoleShard:
+		if err := mgr.checkServerComponentRole(ctx, role); err != nil {
+			mgr.Logger.Info("cluster is unhealthy", "error", err.Error())
+			return false
+		}
+	}
+
 	client, err := mgr.GetReplSetClient(ctx, cluster)
 	if err != nil {
 		mgr.Logger.Info("Get leader client failed", "error", err.Error())
 		return false
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 
 	status, err := GetReplSetStatus(ctx, client)
 	if err != nil {
@@ -625,6 +738,20 @@ func (mgr *Manager) IsClusterHealthy(ctx context.Context, cluster *dcs.Cluster)
 	return isHeathly
 }
 
+func (mgr *Manager) isMongosHealthy(ctx context.Context) bool {
+	client, err := NewMongosClient(ctx, GetConfig().Hosts)
+	if err != nil {
+		mgr.Logger.Info("Get mongos client failed", "error", err.Error())
+		return false
+	}
+
+	if _, err := ListShards(ctx, client); err != nil {
+		mgr.Logger.Info("mongos is unhealthy", "error", err.Error())
+		return false
+	}
+	return true
+}
+
 func (mgr *Manager) IsPromoted(ctx context.Context) bool {
 	isLeader, err := mgr.IsLeader(ctx, nil)
 	if err != nil {
@@ -673,16 +800,13 @@ func (mgr *Manager) Promote(ctx context.Context, cluster *dcs.Cluster) error {
 		}
 	}
 
-	rsConfig.Version++
-
 	hosts := mgr.GetMemberAddrsFromRSConfig(rsConfig)
 	client, err := NewReplSetClient(ctx, hosts)
 	if err != nil {
 		return err
 	}
-	defer client.Disconnect(ctx) //nolint:errcheck
 	mgr.Logger.Info("reconfig replset", "config", rsConfig)
-	return SetReplSetConfig(ctx, client, rsConfig)
+	return mgr.Reconfigure(ctx, client, rsConfig)
 }
 
 func (mgr *Manager) Demote(context.Context) error {
@@ -694,44 +818,52 @@ func (mgr *Manager) Follow(ctx context.Context, cluster *dcs.Cluster) error {
 	return nil
 }
 
-func (mgr *Manager) GetHealthiestMember(cluster *dcs.Cluster, candidate string) *dcs.Member {
-	rsStatus, _ := mgr.GetReplSetStatus(context.TODO())
-	if rsStatus == nil {
-		return nil
+// GetHealthiestMember returns the candidate if it is electable, otherwise the
+// member closest to the primary's optime. Hidden, delayed, arbiter and
+// zero-priority members and members lagging more than the HA config allows
+// are never returned; a *NoCandidateError explains why when nothing qualifies.
+func (mgr *Manager) GetHealthiestMember(cluster *dcs.Cluster, candidate string) (*dcs.Member, error) {
+	ctx := context.TODO()
+	rsStatus, err := mgr.GetReplSetStatus(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet status")
 	}
-	healthyMembers := make([]string, 0, len(rsStatus.Members))
-	var leader string
-	for _, member := range rsStatus.Members {
-		if member.Health == 1 {
-			m := cluster.GetMemberWithHost(member.Name)
-			if m == nil {
-				continue
-			}
-			memberName := m.Name
-			if memberName == candidate {
-				return m
-			}
-			healthyMembers = append(healthyMembers, memberName)
-			if member.State == 1 {
-				leader = memberName
-			}
-		}
+	rsConfig, err := mgr.GetReplSetConfig(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet config")
 	}
 
-	if candidate != "" {
-		mgr.Logger.Info("no health member for candidate", "candidate", candidate)
-		return nil
+	var maxLag int64
+	if cluster.HaConfig != nil {
+		maxLag = cluster.HaConfig.GetMaxLagOnSwitchover()
 	}
+	candidates, rejections := RankCandidates(rsStatus, rsConfig, maxLag)
 
-	if leader != "" {
-		return cluster.GetMemberWithName(leader)
+	for _, c := range candidates {
+		m := cluster.GetMemberWithHost(c.Status.Name)
+		if m == nil {
+			rejections = append(rejections, CandidateRejection{Member: c.Status.Name, Reason: "not found in cluster"})
+			continue
+		}
+		if candidate == "" || m.Name == candidate {
+			return m, nil
+		}
 	}
 
-	// TODO: use lag and other info to pick the healthiest member
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	healthiestMember := healthyMembers[r.Intn(len(healthyMembers))]
-	return cluster.GetMemberWithName(healthiestMember)
-
+	if candidate != "" {
+		mgr.Logger.Info("no health member for candidate", "candidate", candidate)
+		var candidateRejections []CandidateRejection
+		for _, r := range rejections {
+			if m := cluster.GetMemberWithHost(r.Member); m != nil && m.Name == candidate {
+				candidateRejections = append(candidateRejections, r)
+			}
+		}
+		if len(candidateRejections) == 0 {
+			candidateRejections = []CandidateRejection{{Member: candidate, Reason: "not a replica set member"}}
+		}
+		return nil, &NoCandidateError{Candidate: candidate, Rejections: candidateRejections}
+	}
+	return nil, &NoCandidateError{Rejections: rejections}
 }
 
 func (mgr *Manager) HasOtherHealthyLeader(ctx context.Context, cluster *dcs.Cluster) *dcs.Member {
@@ -781,6 +913,7 @@ func (mgr *Manager) HasOtherHealthyLeader(ctx context.Context, cluster *dcs.Clus
 }
 
 // HasOtherHealthyMembers Are there any healthy members other than the leader?
+// Arbiters are not counted, they cannot take over the primary role.
 func (mgr *Manager) HasOtherHealthyMembers(ctx context.Context, cluster *dcs.Cluster, leader string) []*dcs.Member {
 	members := make([]*dcs.Member, 0)
 	rsStatus, _ := mgr.GetReplSetStatus(ctx)
@@ -792,7 +925,7 @@ func (mgr *Manager) HasOtherHealthyMembers(ctx context.Context, cluster *dcs.Clu
 		if member == nil {
 			continue
 		}
-		if member.Health != 1 {
+		if member.Health != 1 || member.State == MemberStateArbiter {
 			continue
 		}
 		m := cluster.GetMemberWithHost(member.Name)
@@ -818,7 +951,7 @@ func (mgr *Manager) Lock(ctx context.Context, reason string) error {
 	}
 	lockResp := LockResp{}
 
-	response := mgr.Client.Database("admin").RunCommand(ctx, m)
+	response := mgr.GetClient().Database("admin").RunCommand(ctx, m)
 	if response.Err() != nil {
 		mgr.Logger.Info(fmt.Sprintf("Lock db (%s) failed", reason), "error", response.Err().Error())
 		return response.Err()
@@ -832,7 +965,7 @@ func (mgr *Manager) Lock(ctx context.Context, reason string) error {
 		err := errors.Errorf("mongo says: %s", lockResp.Errmsg)
 		return err
 	}
-	mgr.IsLocked = true
+	mgr.IsLocked.Store(true)
 	mgr.Logger.Info(fmt.Sprintf("Lock db success times: %d", lockResp.LockCount))
 	return nil
 }
@@ -841,7 +974,7 @@ func (mgr *Manager) Unlock(ctx context.Context) error {
 	mgr.Logger.Info("Unlock db")
 	m := bson.M{"fsyncUnlock": 1}
 	unlockResp := LockResp{}
-	response := mgr.Client.Database("admin").RunCommand(ctx, m)
+	response := mgr.GetClient().Database("admin").RunCommand(ctx, m)
 	if response.Err() != nil {
 		mgr.Logger.Info("Unlock db failed", "error", response.Err().Error())
 		return response.Err()
@@ -856,7 +989,7 @@ func (mgr *Manager) Unlock(ctx context.Context) error {
 		return err
 	}
 	for unlockResp.LockCount > 0 {
-		response = mgr.Client.Database("admin").RunCommand(ctx, m)
+		response = mgr.GetClient().Database("admin").RunCommand(ctx, m)
 		if response.Err() != nil {
 			mgr.Logger.Info("Unlock db failed", "error", response.Err().Error())
 			return response.Err()
@@ -866,7 +999,7 @@ func (mgr *Manager) Unlock(ctx context.Context) error {
 			return err
 		}
 	}
-	mgr.IsLocked = false
+	mgr.IsLocked.Store(false)
 	mgr.Logger.Info("Unlock db success")
 	return nil
 }
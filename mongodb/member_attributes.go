@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// ReconcileMemberAttributes makes the hidden and delayed settings of the
+// replica set members match the ones declared on their pods. Only the primary
+// reconfigures the replica set, and only if something changed.
+func (mgr *Manager) ReconcileMemberAttributes(ctx context.Context, cluster *dcs.Cluster) error {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get replSet client")
+	}
+
+	rsConfig, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get replSet config")
+	}
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get replSet status")
+	}
+	buildInfo, err := GetBuildInfo(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get build info")
+	}
+
+	primary := ""
+	if p := rsStatus.Primary(); p != nil {
+		primary = p.Name
+	}
+	newConfig := rsConfig.DeepCopy()
+	changed := applyClusterAttributes(newConfig, cluster, primary, buildInfo.AtLeast(5, 0))
+	if len(changed) == 0 {
+		return nil
+	}
+	for _, member := range changed {
+		mgr.Logger.Info("member attributes changed", "member", member.Name,
+			"hidden", member.Hidden, "delaySecs", member.DelaySecs)
+	}
+
+	return mgr.Reconfigure(ctx, client, newConfig)
+}
+
+// applyClusterAttributes applies the attributes of the dcs members to the
+// members of rsConfig and returns the dcs members that changed. The primary
+// is left alone, it must be switched over before it can be hidden or delayed.
+func applyClusterAttributes(rsConfig *RSConfig, cluster *dcs.Cluster, primary string, useSecondaryDelaySecs bool) []*dcs.Member {
+	var changed []*dcs.Member
+	for i := range rsConfig.Members {
+		if rsConfig.Members[i].Host == primary {
+			continue
+		}
+		member := cluster.GetMemberWithHost(rsConfig.Members[i].Host)
+		if member == nil {
+			continue
+		}
+		if applyMemberAttributes(&rsConfig.Members[i], member, useSecondaryDelaySecs) {
+			changed = append(changed, member)
+		}
+	}
+	return changed
+}
+
+// applyMemberAttributes sets the hidden and delay fields of the config member
+// from the dcs member and returns true if the config member changed. Hidden
+// and delayed members get priority 0 as the server requires, and voters get
+// the secondary priority back once they are neither. Servers since 5.0 take the
+// delay as secondaryDelaySecs, older ones as slaveDelay.
+func applyMemberAttributes(configMember *ConfigMember, member *dcs.Member, useSecondaryDelaySecs bool) bool {
+	if configMember.ArbiterOnly != nil && *configMember.ArbiterOnly {
+		return false
+	}
+
+	oldHidden := configMember.Hidden != nil && *configMember.Hidden
+	oldDelay := configMember.GetDelaySecs()
+	oldPriority := configMember.Priority
+
+	if member.Hidden {
+		hidden := true
+		configMember.Hidden = &hidden
+	} else if configMember.Hidden != nil {
+		hidden := false
+		configMember.Hidden = &hidden
+	}
+
+	var delayField, otherField **int64
+	if useSecondaryDelaySecs {
+		delayField, otherField = &configMember.SecondaryDelaySecs, &configMember.SlaveDelay
+	} else {
+		delayField, otherField = &configMember.SlaveDelay, &configMember.SecondaryDelaySecs
+	}
+	if member.DelaySecs > 0 || *delayField != nil {
+		delay := member.DelaySecs
+		*delayField = &delay
+	}
+	*otherField = nil
+
+	if member.Hidden || member.DelaySecs > 0 {
+		configMember.Priority = 0
+	} else if (oldHidden || oldDelay > 0) && configMember.IsVoter() {
+		configMember.Priority = SecondaryPriority
+	}
+
+	return oldHidden != member.Hidden || oldDelay != member.DelaySecs ||
+		oldPriority != configMember.Priority
+}
@@ -0,0 +1,112 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func TestApplyMemberAttributes(t *testing.T) {
+	t.Run("hidden and delayed on 5.0", func(t *testing.T) {
+		configMember := &ConfigMember{ID: 1, Host: "pod-1:27017", Priority: SecondaryPriority}
+		member := &dcs.Member{Name: "pod-1", Hidden: true, DelaySecs: 3600}
+
+		assert.True(t, applyMemberAttributes(configMember, member, true))
+		assert.True(t, *configMember.Hidden)
+		assert.Equal(t, int64(3600), *configMember.SecondaryDelaySecs)
+		assert.Nil(t, configMember.SlaveDelay)
+		assert.Equal(t, float64(0), configMember.Priority)
+
+		assert.False(t, applyMemberAttributes(configMember, member, true))
+	})
+
+	t.Run("delayed before 5.0", func(t *testing.T) {
+		configMember := &ConfigMember{ID: 1, Host: "pod-1:27017", Priority: SecondaryPriority}
+		member := &dcs.Member{Name: "pod-1", DelaySecs: 600}
+
+		assert.True(t, applyMemberAttributes(configMember, member, false))
+		assert.Nil(t, configMember.Hidden)
+		assert.Equal(t, int64(600), *configMember.SlaveDelay)
+		assert.Nil(t, configMember.SecondaryDelaySecs)
+		assert.Equal(t, float64(0), configMember.Priority)
+	})
+
+	t.Run("attributes removed", func(t *testing.T) {
+		hidden := true
+		delay := int64(3600)
+		configMember := &ConfigMember{ID: 1, Host: "pod-1:27017", Hidden: &hidden, SecondaryDelaySecs: &delay}
+		member := &dcs.Member{Name: "pod-1"}
+
+		assert.True(t, applyMemberAttributes(configMember, member, true))
+		assert.False(t, *configMember.Hidden)
+		assert.Equal(t, int64(0), *configMember.SecondaryDelaySecs)
+		assert.Equal(t, float64(SecondaryPriority), configMember.Priority)
+	})
+
+	t.Run("plain member is left alone", func(t *testing.T) {
+		configMember := &ConfigMember{ID: 0, Host: "pod-0:27017", Priority: PrimaryPriority}
+
+		assert.False(t, applyMemberAttributes(configMember, &dcs.Member{Name: "pod-0"}, true))
+		assert.Nil(t, configMember.Hidden)
+		assert.Nil(t, configMember.SecondaryDelaySecs)
+		assert.Equal(t, float64(PrimaryPriority), configMember.Priority)
+	})
+
+	t.Run("arbiter is left alone", func(t *testing.T) {
+		arbiter := true
+		configMember := &ConfigMember{ID: 2, Host: "pod-2:27017", ArbiterOnly: &arbiter}
+
+		assert.False(t, applyMemberAttributes(configMember, &dcs.Member{Name: "pod-2", Hidden: true}, true))
+		assert.Nil(t, configMember.Hidden)
+	})
+}
+
+func TestApplyClusterAttributes(t *testing.T) {
+	cluster := &dcs.Cluster{Members: []dcs.Member{
+		{Name: "pod-0", PodIP: "10.0.0.1", Hidden: true},
+		{Name: "pod-1", PodIP: "10.0.0.2", DelaySecs: 600},
+	}}
+	rsConfig := mockRSConfig(2, 0)
+
+	// the marker on the primary is ignored until it is switched over
+	changed := applyClusterAttributes(rsConfig, cluster, "pod-0:27017", true)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "pod-1", changed[0].Name)
+	assert.Nil(t, rsConfig.Members[0].Hidden)
+	assert.Equal(t, float64(SecondaryPriority), rsConfig.Members[0].Priority)
+	assert.Equal(t, float64(0), rsConfig.Members[1].Priority)
+
+	changed = applyClusterAttributes(rsConfig, cluster, "pod-1:27017", true)
+	assert.Len(t, changed, 1)
+	assert.Equal(t, "pod-0", changed[0].Name)
+	assert.True(t, *rsConfig.Members[0].Hidden)
+	assert.Equal(t, float64(0), rsConfig.Members[0].Priority)
+}
+
+func TestBuildInfoAtLeast(t *testing.T) {
+	assert.True(t, (&BuildInfo{VersionArray: []int{5, 0, 3, 0}}).AtLeast(5, 0))
+	assert.True(t, (&BuildInfo{VersionArray: []int{6, 0, 0, 0}}).AtLeast(5, 0))
+	assert.False(t, (&BuildInfo{VersionArray: []int{4, 4, 18, 0}}).AtLeast(5, 0))
+	assert.False(t, (&BuildInfo{}).AtLeast(5, 0))
+}
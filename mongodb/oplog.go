@@ -0,0 +1,63 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetOplogWindow returns the timestamps of the oldest and the newest entries
+// of the oplog of the server the client is connected to.
+func GetOplogWindow(ctx context.Context, client *mongo.Client) (*OplogWindow, error) {
+	first, err := getOplogEntryTimestamp(ctx, client, 1)
+	if err != nil {
+		return nil, err
+	}
+	last, err := getOplogEntryTimestamp(ctx, client, -1)
+	if err != nil {
+		return nil, err
+	}
+	return &OplogWindow{First: first, Last: last}, nil
+}
+
+func getOplogEntryTimestamp(ctx context.Context, client *mongo.Client, order int) (primitive.Timestamp, error) {
+	entry := struct {
+		Timestamp primitive.Timestamp `bson:"ts"`
+	}{}
+
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "$natural", Value: order}}).
+		SetProjection(bson.D{{Key: "ts", Value: 1}})
+	res := client.Database("local").Collection("oplog.rs").FindOne(ctx, bson.D{}, opts)
+	if res.Err() != nil {
+		return entry.Timestamp, errors.Wrap(res.Err(), "find oplog entry")
+	}
+
+	if err := res.Decode(&entry); err != nil {
+		return entry.Timestamp, errors.Wrap(err, "failed to decode oplog entry")
+	}
+	return entry.Timestamp, nil
+}
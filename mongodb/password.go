@@ -0,0 +1,126 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// RotateRootPassword changes the root password on the primary and swaps the
+// config in use for one with the new password. The previous password is still
+// tried for the grace period, so that clients keep connecting to members that
+// have not replicated the change yet. It returns right away with the end of
+// the grace period, when the previous password is dropped and the clients
+// that may have fallen back to it are replaced. Rotating to the password in
+// use is a no-op, so the rotation can be repeated on every member.
+func (mgr *Manager) RotateRootPassword(ctx context.Context, cluster *dcs.Cluster, password string) (time.Time, error) {
+	if password == "" {
+		return time.Time{}, errors.New("password must be set")
+	}
+
+	oldConfig := GetConfig()
+	if password == oldConfig.Password {
+		return oldConfig.PreviousPasswordExpiry, nil
+	}
+
+	// the rotation may already have been applied through another member, the
+	// leader client tries both passwords
+	newConfig := rotatedConfig(oldConfig, password, time.Now())
+	SwapConfig(newConfig)
+
+	err := mgr.applyRootPassword(ctx, cluster, newConfig)
+	if err != nil {
+		SwapConfig(oldConfig)
+		mgr.Logger.Info("Rotate root password failed", "error", err.Error())
+		return time.Time{}, err
+	}
+
+	mgr.Logger.Info("Root password rotated", "user", newConfig.Username, "graceUntil", newConfig.PreviousPasswordExpiry)
+	time.AfterFunc(time.Until(newConfig.PreviousPasswordExpiry), func() {
+		mgr.endPasswordGrace(newConfig)
+	})
+	return newConfig.PreviousPasswordExpiry, nil
+}
+
+// endPasswordGrace drops the previous password of the rotated config, unless
+// another rotation replaced it meanwhile. Clients connect only once, so the
+// cached ones and the root client, which may have fallen back to the previous
+// password, are replaced.
+func (mgr *Manager) endPasswordGrace(config *Config) {
+	ended := config.DeepCopy()
+	ended.PreviousPassword = ""
+	ended.PreviousPasswordExpiry = time.Time{}
+	if !currentConfig.CompareAndSwap(config, ended) {
+		return
+	}
+	clients.InvalidateAll()
+
+	client, err := NewMongodbClient(context.Background(), ended)
+	if err != nil {
+		mgr.Logger.Info("Reconnect after the password grace period failed", "error", err.Error())
+		return
+	}
+	mgr.replaceClient(client)
+	mgr.Logger.Info("Password grace period ended", "user", ended.Username)
+}
+
+func (mgr *Manager) applyRootPassword(ctx context.Context, cluster *dcs.Cluster, config *Config) error {
+	client, err := mgr.GetLeaderClient(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get leader client")
+	}
+
+	if err = UpdateUserPass(ctx, client, config.Username, config.Password); err != nil {
+		return errors.Wrap(err, "update root password")
+	}
+
+	// pooled connections stay authenticated, but new ones of the manager's
+	// client would still use the previous password
+	newClient, err := NewMongodbClient(ctx, config)
+	if err != nil {
+		return errors.Wrap(err, "reconnect with the new password")
+	}
+	mgr.replaceClient(newClient)
+	return nil
+}
+
+// replaceClient replaces the root client of the manager. The previous one is
+// disconnected later, since operations may still use it.
+func (mgr *Manager) replaceClient(client *mongo.Client) {
+	if oldClient := mgr.client.Swap(client); oldClient != nil {
+		retireClient(oldClient)
+	}
+}
+
+// rotatedConfig returns a copy of the config with the new password, keeping
+// the current one as the previous password for the grace period.
+func rotatedConfig(config *Config, password string, now time.Time) *Config {
+	newConfig := config.DeepCopy()
+	newConfig.PreviousPassword = config.Password
+	newConfig.PreviousPasswordExpiry = now.Add(config.PasswordGracePeriod)
+	newConfig.Password = password
+	return newConfig
+}
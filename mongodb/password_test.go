@@ -0,0 +1,111 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestRotatedConfig(t *testing.T) {
+	now := time.Now()
+	config := &Config{
+		Hosts:               []string{"localhost:27017"},
+		Username:            "root",
+		Password:            "old",
+		PasswordGracePeriod: time.Minute,
+	}
+
+	newConfig := rotatedConfig(config, "new", now)
+	assert.Equal(t, "new", newConfig.Password)
+	assert.Equal(t, "old", newConfig.PreviousPassword)
+	assert.Equal(t, now.Add(time.Minute), newConfig.PreviousPasswordExpiry)
+	assert.Equal(t, "old", config.Password)
+	assert.False(t, config.InPasswordGrace(now))
+
+	assert.True(t, newConfig.InPasswordGrace(now))
+	assert.True(t, newConfig.InPasswordGrace(now.Add(59*time.Second)))
+	assert.False(t, newConfig.InPasswordGrace(now.Add(time.Minute)))
+}
+
+func TestReplaceClient(t *testing.T) {
+	newClient := func() *mongo.Client {
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+		assert.Nil(t, err)
+		return client
+	}
+	mgr := &Manager{}
+	assert.Nil(t, mgr.GetClient())
+
+	oldClient, rotatedClient := newClient(), newClient()
+	mgr.replaceClient(oldClient)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.NotNil(t, mgr.GetClient())
+			}
+		}()
+	}
+	mgr.replaceClient(rotatedClient)
+	wg.Wait()
+
+	assert.Same(t, rotatedClient, mgr.GetClient())
+	// the previous client is retired later, operations may still use it
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.NotErrorIs(t, oldClient.Ping(ctx, nil), mongo.ErrClientDisconnected)
+}
+
+func TestEndPasswordGrace(t *testing.T) {
+	old := GetConfig()
+	t.Cleanup(func() { currentConfig.Store(old) })
+	config, err := NewConfig(map[string]string{})
+	assert.Nil(t, err)
+	config.Hosts = []string{"127.0.0.1:1"}
+	rotated := rotatedConfig(config, "new", time.Now())
+	currentConfig.Store(rotated)
+	_, err = NewLocalUnauthClient(context.Background())
+	assert.Nil(t, err)
+
+	mgr := &Manager{}
+	mgr.endPasswordGrace(rotated)
+	assert.Equal(t, "new", GetConfig().Password)
+	assert.Equal(t, "", GetConfig().PreviousPassword)
+	assert.NotNil(t, mgr.GetClient())
+	for _, stats := range clients.Stats() {
+		assert.NotEqual(t, []string{"127.0.0.1:1"}, stats.Hosts)
+	}
+
+	// a later rotation is not undone by the timer of the previous one
+	later := rotatedConfig(GetConfig(), "newer", time.Now())
+	currentConfig.Store(later)
+	mgr.endPasswordGrace(rotated)
+	assert.Same(t, later, GetConfig())
+}
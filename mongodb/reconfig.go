@@ -0,0 +1,250 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	reconfigPollInterval  = time.Second
+	reconfigCommitTimeout = time.Minute
+)
+
+// PlanReconfig splits the change from current to desired into a sequence of
+// configs that the server accepts in a non-force reconfiguration: each config
+// adds, removes or changes the vote of at most one voting member compared to
+// the previous one. Changes that do not affect voters are made in the first
+// step. Voters are added before others are removed, unless that would exceed
+// MaxVotingMembers. The returned configs carry increasing versions and the
+// fields of desired other than the members.
+func PlanReconfig(current, desired *RSConfig) ([]*RSConfig, error) {
+	if len(desired.Members) > MaxMembers {
+		return nil, errors.Errorf("config has %d members, the maximum is %d", len(desired.Members), MaxMembers)
+	}
+	if desired.VoterCount() > MaxVotingMembers {
+		return nil, errors.Errorf("config has %d voting members, the maximum is %d", desired.VoterCount(), MaxVotingMembers)
+	}
+
+	currentMembers := map[int]ConfigMember{}
+	for _, m := range current.Members {
+		currentMembers[m.ID] = m
+	}
+	desiredMembers := map[int]ConfigMember{}
+	for _, m := range desired.Members {
+		desiredMembers[m.ID] = m
+	}
+
+	// the first step takes every change of non-voters and keeps the voter
+	// changes for later
+	var additions, removals []int
+	first := desired.DeepCopy()
+	first.Members = first.Members[:0]
+	for _, m := range desired.Members {
+		cur, ok := currentMembers[m.ID]
+		switch {
+		case !isVoterChange(cur, ok, m):
+			first.Members = append(first.Members, m)
+		case ok && cur.IsVoter():
+			// a voter turned non-voter, or replaced by a new host
+			removals = append(removals, m.ID)
+			first.Members = append(first.Members, cur)
+			if m.IsVoter() {
+				additions = append(additions, m.ID)
+			}
+		default:
+			additions = append(additions, m.ID)
+			if ok {
+				first.Members = append(first.Members, cur)
+			}
+		}
+	}
+	for _, m := range current.Members {
+		if _, ok := desiredMembers[m.ID]; ok {
+			continue
+		}
+		if m.IsVoter() {
+			removals = append(removals, m.ID)
+			first.Members = append(first.Members, m)
+		}
+	}
+	sort.Ints(additions)
+	sort.Ints(removals)
+	sortMembers(first.Members)
+
+	var steps []*RSConfig
+	version := current.Version
+	last := current
+	if !sameConfig(current, first) {
+		version++
+		first.Version = version
+		steps = append(steps, first)
+		last = first
+	}
+
+	for len(additions) > 0 || len(removals) > 0 {
+		next := last.DeepCopy()
+		var id int
+		// a member that is replaced by a new host is removed before it is added
+		// back, and the set never has more voters than MaxVotingMembers
+		if len(additions) > 0 && !contains(removals, additions[0]) &&
+			(next.VoterCount() < MaxVotingMembers || len(removals) == 0) {
+			id, additions = additions[0], additions[1:]
+		} else {
+			id, removals = removals[0], removals[1:]
+		}
+
+		next.Members = next.Members[:0]
+		for _, m := range last.Members {
+			if m.ID != id {
+				next.Members = append(next.Members, m)
+			}
+		}
+		target, ok := desiredMembers[id]
+		if ok && (target.IsVoter() && !contains(additions, id) || !target.IsVoter()) {
+			next.Members = append(next.Members, target)
+		} else if ok {
+			// removed as voter now, added back as voter later
+			nonVoter := currentMembers[id]
+			votes := 0
+			nonVoter.Votes = &votes
+			nonVoter.Priority = 0
+			next.Members = append(next.Members, nonVoter)
+		}
+		sortMembers(next.Members)
+
+		version++
+		next.Version = version
+		steps = append(steps, next)
+		last = next
+	}
+	return steps, nil
+}
+
+// isVoterChange returns true if turning the current member, if it exists,
+// into the desired one changes the voters of the replica set.
+func isVoterChange(current ConfigMember, exists bool, desired ConfigMember) bool {
+	currentVoter := exists && current.IsVoter()
+	desiredVoter := desired.IsVoter()
+	if currentVoter != desiredVoter {
+		return true
+	}
+	return currentVoter && current.Host != desired.Host
+}
+
+func sameConfig(a, b *RSConfig) bool {
+	x, y := *a, *b
+	x.Version, y.Version = 0, 0
+	return reflect.DeepEqual(&x, &y)
+}
+
+func sortMembers(members ConfigMembers) {
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+}
+
+func contains(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// IsConfigCommitted returns true if a majority of the voting members of
+// rsConfig report its version in the status, and, on servers that report
+// config terms, the same config term as the primary.
+func IsConfigCommitted(status *ReplSetStatus, rsConfig *RSConfig) bool {
+	var term int64
+	if primary := status.Primary(); primary != nil {
+		term = primary.ConfigTerm
+	}
+
+	voters := map[int]bool{}
+	for i := range rsConfig.Members {
+		if rsConfig.Members[i].IsVoter() {
+			voters[rsConfig.Members[i].ID] = true
+		}
+	}
+
+	committed := 0
+	for _, m := range status.Members {
+		if !voters[m.ID] || m.Health != MemberHealthUp {
+			continue
+		}
+		if m.ConfigVersion < rsConfig.Version {
+			continue
+		}
+		if term > 0 && m.ConfigVersion == rsConfig.Version && m.ConfigTerm != term {
+			continue
+		}
+		committed++
+	}
+	return committed > len(voters)/2
+}
+
+// Reconfigure changes the replica set config to desired through the steps
+// planned by PlanReconfig, waiting for each step to be committed before
+// running the next one.
+func (mgr *Manager) Reconfigure(ctx context.Context, client *mongo.Client, desired *RSConfig) error {
+	current, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get replSet config")
+	}
+
+	steps, err := PlanReconfig(current, desired)
+	if err != nil {
+		return err
+	}
+	for i, step := range steps {
+		mgr.Logger.Info("reconfig replset", "step", fmt.Sprintf("%d/%d", i+1, len(steps)), "version", step.Version)
+		if err = SetReplSetConfig(ctx, client, step); err != nil {
+			return errors.Wrapf(err, "reconfig step %d/%d", i+1, len(steps))
+		}
+		if err = mgr.waitConfigCommitted(ctx, client, step); err != nil {
+			return errors.Wrapf(err, "reconfig step %d/%d", i+1, len(steps))
+		}
+	}
+	return nil
+}
+
+func (mgr *Manager) waitConfigCommitted(ctx context.Context, client *mongo.Client, rsConfig *RSConfig) error {
+	waitCtx, cancel := context.WithTimeout(ctx, reconfigCommitTimeout)
+	defer cancel()
+	for {
+		status, err := GetReplSetStatus(waitCtx, client)
+		if err == nil && IsConfigCommitted(status, rsConfig) {
+			return nil
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return errors.Errorf("timed out waiting for config version %d to be committed", rsConfig.Version)
+		case <-time.After(reconfigPollInterval):
+		}
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// assertLegalSteps checks that every step changes at most one voter and that
+// the last step matches the desired members.
+func assertLegalSteps(t *testing.T, current, desired *RSConfig, steps []*RSConfig) {
+	prev := current
+	for i, step := range steps {
+		assert.Equal(t, prev.Version+1, step.Version, "step %d version", i)
+		assert.LessOrEqual(t, step.VoterCount(), MaxVotingMembers, "step %d voters", i)
+
+		prevVoters := map[string]bool{}
+		for _, m := range prev.Members {
+			if m.IsVoter() {
+				prevVoters[fmt.Sprintf("%d/%s", m.ID, m.Host)] = true
+			}
+		}
+		changes := 0
+		for _, m := range step.Members {
+			key := fmt.Sprintf("%d/%s", m.ID, m.Host)
+			if m.IsVoter() && !prevVoters[key] {
+				changes++
+			}
+			delete(prevVoters, key)
+		}
+		for key := range prevVoters {
+			if !memberVotes(step, key) {
+				changes++
+			}
+		}
+		assert.LessOrEqual(t, changes, 1, "step %d voter changes", i)
+		prev = step
+	}
+	if len(steps) > 0 {
+		assert.Equal(t, desired.Members, steps[len(steps)-1].Members)
+	}
+}
+
+func memberVotes(rsConfig *RSConfig, key string) bool {
+	for _, m := range rsConfig.Members {
+		if fmt.Sprintf("%d/%s", m.ID, m.Host) == key && m.IsVoter() {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPlanReconfig(t *testing.T) {
+	t.Run("no change", func(t *testing.T) {
+		current := mockRSConfig(3, 0)
+		steps, err := PlanReconfig(current, current.DeepCopy())
+		assert.Nil(t, err)
+		assert.Empty(t, steps)
+	})
+
+	t.Run("priority change in one step", func(t *testing.T) {
+		current := mockRSConfig(3, 1)
+		current.Version = 4
+		desired := current.DeepCopy()
+		desired.Members[0].Priority = PrimaryPriority
+		desired.Members[1].Priority = 0.5
+		desired.Members = desired.Members[:3]
+
+		steps, err := PlanReconfig(current, desired)
+		assert.Nil(t, err)
+		assert.Len(t, steps, 1)
+		assertLegalSteps(t, current, desired, steps)
+	})
+
+	t.Run("add two voters", func(t *testing.T) {
+		current := mockRSConfig(3, 0)
+		desired := mockRSConfig(5, 0)
+		desired.Members[0].Priority = PrimaryPriority
+
+		steps, err := PlanReconfig(current, desired)
+		assert.Nil(t, err)
+		assert.Len(t, steps, 3)
+		assertLegalSteps(t, current, desired, steps)
+	})
+
+	t.Run("replace the host of a voter", func(t *testing.T) {
+		current := mockRSConfig(3, 0)
+		desired := current.DeepCopy()
+		desired.Members[2].Host = "pod-2-new:27017"
+
+		steps, err := PlanReconfig(current, desired)
+		assert.Nil(t, err)
+		assert.Len(t, steps, 2)
+		assert.False(t, steps[0].Members[2].IsVoter())
+		assert.Equal(t, "pod-2:27017", steps[0].Members[2].Host)
+		assertLegalSteps(t, current, desired, steps)
+	})
+
+	t.Run("swap voters at the voter limit", func(t *testing.T) {
+		current := mockRSConfig(7, 1)
+		desired := mockRSConfig(7, 1)
+		votes := 0
+		desired.Members[3].Votes = &votes
+		desired.Members[3].Priority = 0
+		desired.Members[7].Votes = nil
+		desired.Members[7].Priority = SecondaryPriority
+
+		steps, err := PlanReconfig(current, desired)
+		assert.Nil(t, err)
+		assert.Len(t, steps, 2)
+		assert.False(t, steps[0].Members[3].IsVoter())
+		assertLegalSteps(t, current, desired, steps)
+	})
+
+	t.Run("remove a voter and promote a non-voter", func(t *testing.T) {
+		current := mockRSConfig(7, 2)
+		desired := current.DeepCopy()
+		desired.Members = append(desired.Members[:1], desired.Members[2:]...)
+		promoteNonVoter(desired)
+
+		steps, err := PlanReconfig(current, desired)
+		assert.Nil(t, err)
+		assert.Len(t, steps, 2)
+		assertLegalSteps(t, current, desired, steps)
+	})
+
+	t.Run("too many voters", func(t *testing.T) {
+		_, err := PlanReconfig(mockRSConfig(7, 0), mockRSConfig(8, 0))
+		assert.EqualError(t, err, "config has 8 voting members, the maximum is 7")
+	})
+}
+
+// statusFixture is a replSetGetStatus reply from a MongoDB 6.0 replica set
+// right after a reconfig to version 6: pod-1 already has the new config,
+// pod-2 is still on version 5 and pod-3 is down.
+const statusFixture = `{
+	"set": "rs0",
+	"myState": {"$numberInt": "1"},
+	"term": {"$numberLong": "3"},
+	"members": [
+		{"_id": {"$numberInt": "0"}, "name": "pod-0:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "1"}, "stateStr": "PRIMARY", "configVersion": {"$numberInt": "6"}, "configTerm": {"$numberLong": "3"}, "self": true},
+		{"_id": {"$numberInt": "1"}, "name": "pod-1:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "2"}, "stateStr": "SECONDARY", "configVersion": {"$numberInt": "6"}, "configTerm": {"$numberLong": "3"}},
+		{"_id": {"$numberInt": "2"}, "name": "pod-2:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "2"}, "stateStr": "SECONDARY", "configVersion": {"$numberInt": "5"}, "configTerm": {"$numberLong": "3"}},
+		{"_id": {"$numberInt": "3"}, "name": "pod-3:27017", "health": {"$numberDouble": "0.0"}, "state": {"$numberInt": "8"}, "stateStr": "(not reachable/healthy)", "configVersion": {"$numberInt": "-1"}, "configTerm": {"$numberLong": "-1"}}
+	],
+	"ok": {"$numberDouble": "1.0"}
+}`
+
+// legacyStatusFixture is a replSetGetStatus reply from a MongoDB 4.2 replica
+// set, which reports no config terms.
+const legacyStatusFixture = `{
+	"set": "rs0",
+	"myState": {"$numberInt": "1"},
+	"members": [
+		{"_id": {"$numberInt": "0"}, "name": "pod-0:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "1"}, "stateStr": "PRIMARY", "configVersion": {"$numberInt": "6"}, "self": true},
+		{"_id": {"$numberInt": "1"}, "name": "pod-1:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "2"}, "stateStr": "SECONDARY", "configVersion": {"$numberInt": "6"}},
+		{"_id": {"$numberInt": "2"}, "name": "pod-2:27017", "health": {"$numberDouble": "1.0"}, "state": {"$numberInt": "2"}, "stateStr": "SECONDARY", "configVersion": {"$numberInt": "5"}}
+	],
+	"ok": {"$numberDouble": "1.0"}
+}`
+
+func decodeStatus(t *testing.T, fixture string) *ReplSetStatus {
+	raw := bson.M{}
+	require.NoError(t, bson.UnmarshalExtJSON([]byte(fixture), false, &raw))
+	data, err := bson.Marshal(raw)
+	require.NoError(t, err)
+
+	status := &ReplSetStatus{}
+	require.NoError(t, bson.Unmarshal(data, status))
+	return status
+}
+
+func TestIsConfigCommitted(t *testing.T) {
+	status := decodeStatus(t, statusFixture)
+
+	t.Run("majority of four voters", func(t *testing.T) {
+		rsConfig := mockRSConfig(4, 0)
+		rsConfig.Version = 6
+		assert.False(t, IsConfigCommitted(status, rsConfig))
+	})
+
+	t.Run("majority of three voters", func(t *testing.T) {
+		rsConfig := mockRSConfig(3, 1)
+		rsConfig.Version = 6
+		assert.True(t, IsConfigCommitted(status, rsConfig))
+	})
+
+	t.Run("config term mismatch", func(t *testing.T) {
+		stale := decodeStatus(t, statusFixture)
+		stale.Members[1].ConfigTerm = 2
+		rsConfig := mockRSConfig(3, 1)
+		rsConfig.Version = 6
+		assert.False(t, IsConfigCommitted(stale, rsConfig))
+	})
+
+	t.Run("server without config terms", func(t *testing.T) {
+		rsConfig := mockRSConfig(3, 0)
+		rsConfig.Version = 6
+		assert.True(t, IsConfigCommitted(decodeStatus(t, legacyStatusFixture), rsConfig))
+		rsConfig.Version = 7
+		assert.False(t, IsConfigCommitted(decodeStatus(t, legacyStatusFixture), rsConfig))
+	})
+}
@@ -0,0 +1,167 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// OplogWindowWarningRatio is the share of the oplog window a secondary may
+// lag behind before it is reported as about to fall off the oplog.
+const OplogWindowWarningRatio = 0.8
+
+// MemberReplication is how far a member is behind the primary.
+type MemberReplication struct {
+	Host    string
+	State   string
+	Healthy bool
+	LagSecs int64
+	// LagOps is the number of oplog entries of the primary the member has
+	// not applied yet, estimated from the average rate of the oplog window
+	// unless they were counted.
+	LagOps int64
+	// Warning is set if the lag approaches the oplog window.
+	Warning string
+}
+
+// ReplicationHealth is the replication lag of every member and the oplog
+// window of the primary.
+type ReplicationHealth struct {
+	Members     []MemberReplication
+	OplogWindow *OplogWindow
+	Warnings    []string
+}
+
+// OplogWindowHours returns how many hours of operations the oplog holds.
+func (h *ReplicationHealth) OplogWindowHours() float64 {
+	return float64(h.OplogWindow.Seconds()) / 3600
+}
+
+// GetReplicationHealth reads the lag of every member from the replica set
+// status and estimates the operations they miss in the oplog of the primary.
+// If count is set, the operations are counted instead, which scans the oplog
+// and is too costly to run on every scrape.
+func (mgr *Manager) GetReplicationHealth(ctx context.Context, cluster *dcs.Cluster, count bool) (*ReplicationHealth, error) {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet client")
+	}
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet status")
+	}
+	primary := rsStatus.Primary()
+	if primary == nil {
+		return nil, errors.New("replica set has no primary")
+	}
+	window, err := GetOplogWindow(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get oplog window")
+	}
+
+	oplogEntries, err := EstimateOplogEntries(ctx, client)
+	if err != nil {
+		mgr.Logger.Info("estimate oplog entries failed", "error", err.Error())
+	}
+
+	health := &ReplicationHealth{OplogWindow: window}
+	for _, status := range rsStatus.Members {
+		if status == nil {
+			continue
+		}
+		member := MemberReplication{
+			Host:    status.Name,
+			State:   status.StateStr,
+			Healthy: status.Health == MemberHealthUp,
+		}
+		if status.State != MemberStateArbiter && status.State != MemberStatePrimary {
+			member.LagSecs = optimeLag(primary.Optime, status.Optime)
+			member.LagOps = estimateLagOps(oplogEntries, window, member.LagSecs)
+			if count && status.Optime != nil && timestampBefore(status.Optime.Timestamp, window.Last) {
+				lagOps, err := CountOplogEntriesAfter(ctx, client, status.Optime.Timestamp)
+				if err != nil {
+					mgr.Logger.Info("count oplog entries failed, keep the estimate", "member", status.Name, "error", err.Error())
+				} else {
+					member.LagOps = lagOps
+				}
+			}
+		}
+		health.Members = append(health.Members, member)
+	}
+	health.Warnings = warnLagNearOplogWindow(health.Members, window)
+	return health, nil
+}
+
+// warnLagNearOplogWindow warns about the secondaries whose lag approaches
+// the oplog window, they are about to need a resync.
+func warnLagNearOplogWindow(members []MemberReplication, window *OplogWindow) []string {
+	var warnings []string
+	limit := float64(window.Seconds()) * OplogWindowWarningRatio
+	for i := range members {
+		member := &members[i]
+		if member.LagSecs > 0 && float64(member.LagSecs) >= limit {
+			member.Warning = fmt.Sprintf("%s lags %ds behind the primary, the oplog window is %ds",
+				member.Host, member.LagSecs, window.Seconds())
+			warnings = append(warnings, member.Warning)
+		}
+	}
+	return warnings
+}
+
+// estimateLagOps estimates the oplog entries written during the lag from the
+// average rate of the oplog window.
+func estimateLagOps(oplogEntries int64, window *OplogWindow, lagSecs int64) int64 {
+	windowSecs := window.Seconds()
+	if oplogEntries <= 0 || windowSecs <= 0 || lagSecs <= 0 {
+		return 0
+	}
+	if lagSecs >= windowSecs {
+		return oplogEntries
+	}
+	return oplogEntries * lagSecs / windowSecs
+}
+
+// EstimateOplogEntries returns the number of oplog entries from the metadata
+// of the oplog, without scanning it.
+func EstimateOplogEntries(ctx context.Context, client *mongo.Client) (int64, error) {
+	count, err := client.Database("local").Collection("oplog.rs").EstimatedDocumentCount(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "estimate oplog entries")
+	}
+	return count, nil
+}
+
+// CountOplogEntriesAfter returns the number of oplog entries newer than ts.
+func CountOplogEntriesAfter(ctx context.Context, client *mongo.Client, ts primitive.Timestamp) (int64, error) {
+	count, err := client.Database("local").Collection("oplog.rs").
+		CountDocuments(ctx, bson.D{{Key: "ts", Value: bson.D{{Key: "$gt", Value: ts}}}})
+	if err != nil {
+		return 0, errors.Wrap(err, "count oplog entries")
+	}
+	return count, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWarnLagNearOplogWindow(t *testing.T) {
+	window := &OplogWindow{First: primitive.Timestamp{T: 1000}, Last: primitive.Timestamp{T: 8200}}
+	members := []MemberReplication{
+		{Host: "10.0.0.1:27017"},
+		{Host: "10.0.0.2:27017", LagSecs: 5},
+		{Host: "10.0.0.3:27017", LagSecs: 6000},
+	}
+
+	warnings := warnLagNearOplogWindow(members, window)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "", members[1].Warning)
+	assert.Equal(t, "10.0.0.3:27017 lags 6000s behind the primary, the oplog window is 7200s", members[2].Warning)
+
+	health := &ReplicationHealth{OplogWindow: window}
+	assert.Equal(t, 2.0, health.OplogWindowHours())
+}
+
+func TestEstimateLagOps(t *testing.T) {
+	window := &OplogWindow{First: primitive.Timestamp{T: 1000}, Last: primitive.Timestamp{T: 8200}}
+
+	assert.Equal(t, int64(100), estimateLagOps(7200, window, 100))
+	assert.Equal(t, int64(0), estimateLagOps(7200, window, 0))
+	// a member off the oplog window misses every entry of the oplog
+	assert.Equal(t, int64(7200), estimateLagOps(7200, window, 9000))
+	// the oplog entries could not be estimated
+	assert.Equal(t, int64(0), estimateLagOps(0, window, 100))
+	assert.Equal(t, int64(0), estimateLagOps(7200, &OplogWindow{}, 100))
+}
@@ -90,3 +90,174 @@ func GetReplSetConfig(ctx context.Context, client *mongo.Client) (*RSConfig, err
 
 	return resp.Config, nil
 }
+
+// StepDown asks the primary to step down once an electable secondary has
+// caught up within catchUpSecs, and not to seek reelection for stepDownSecs.
+func StepDown(ctx context.Context, client *mongo.Client, stepDownSecs, catchUpSecs int) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "replSetStepDown", Value: stepDownSecs},
+		{Key: "secondaryCatchUpPeriodSecs", Value: catchUpSecs},
+	})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "replSetStepDown")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode replSetStepDown response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
+
+// Freeze prevents a secondary from seeking election for secs seconds, a zero
+// value unfreezes it.
+func Freeze(ctx context.Context, client *mongo.Client, secs int) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetFreeze", Value: secs}})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "replSetFreeze")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode replSetFreeze response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
+
+// GetIsMaster runs isMaster, which needs no authentication, so it also works
+// on arbiters that hold no user data.
+func GetIsMaster(ctx context.Context, client *mongo.Client) (*IsMasterResp, error) {
+	resp := &IsMasterResp{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "isMaster")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode isMaster response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+// GetElectionMetrics returns the elections the server called, nil if the
+// server does not report them.
+func GetElectionMetrics(ctx context.Context, client *mongo.Client) (*ElectionMetrics, error) {
+	resp := struct {
+		ElectionMetrics *ElectionMetrics `bson:"electionMetrics"`
+		OKResponse      `bson:",inline"`
+	}{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "serverStatus", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "serverStatus")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode serverStatus response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp.ElectionMetrics, nil
+}
+
+// IsArbiter returns true if the server the client is connected to is an
+// arbiter.
+func IsArbiter(ctx context.Context, client *mongo.Client) (bool, error) {
+	resp, err := GetIsMaster(ctx, client)
+	if err != nil {
+		return false, err
+	}
+
+	return resp.IsArbiter, nil
+}
+
+// PinDefaultWriteConcern sets the cluster-wide default write concern to the
+// implicit one if none is set. Since 5.0 the server rejects adding or removing
+// an arbiter while the implicit default write concern would change, pinning
+// it keeps the current behavior and lets the reconfiguration go through.
+func PinDefaultWriteConcern(ctx context.Context, client *mongo.Client) error {
+	resp := DefaultRWConcernResp{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "getDefaultRWConcern", Value: 1}})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "getDefaultRWConcern")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode getDefaultRWConcern response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	// servers before 5.0 do not derive the default write concern from the
+	// topology and report no source
+	if resp.DefaultWriteConcernSource != "implicit" || resp.DefaultWriteConcern == nil {
+		return nil
+	}
+
+	res = client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "setDefaultRWConcern", Value: 1},
+		{Key: "defaultWriteConcern", Value: resp.DefaultWriteConcern},
+	})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "setDefaultRWConcern")
+	}
+
+	setResp := OKResponse{}
+	if err := res.Decode(&setResp); err != nil {
+		return errors.Wrap(err, "failed to decode setDefaultRWConcern response")
+	}
+
+	if setResp.OK != 1 {
+		return errors.Errorf("mongo says: %s", setResp.Errmsg)
+	}
+
+	return nil
+}
+
+// ForceReplSetConfig installs the config with a forced reconfiguration, which
+// the server accepts without a majority of the members.
+func ForceReplSetConfig(ctx context.Context, client *mongo.Client, cfg *RSConfig) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "replSetReconfig", Value: cfg},
+		{Key: "force", Value: true},
+	})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "replSetReconfig with force")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode to replSetReconfigResponse")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,392 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+const (
+	resyncPollInterval = time.Second
+	// resyncTimeout bounds the wait for mongod to stop and to come back with
+	// an empty data directory, if the caller set no deadline.
+	resyncTimeout = 10 * time.Minute
+)
+
+// mongodLockFile is the file mongod locks in its dbPath while it runs.
+const mongodLockFile = "mongod.lock"
+
+// dataDirMarkers are files found in every dbPath of mongod.
+var dataDirMarkers = []string{"WiredTiger", "storage.bson", mongodLockFile}
+
+// ResyncResult is the outcome of a resync of the current member.
+type ResyncResult struct {
+	Member          string
+	State           string
+	LagSecs         int64
+	OplogWindowSecs int64
+	Message         string
+}
+
+// ResyncMember rebuilds the data of the current member with an initial sync.
+// The member is removed from the replica set, mongod is shut down and its
+// data directory wiped, and the member is added back once mongod has been
+// restarted empty. It must not be the primary and the other voting members
+// must keep a majority. Unless force is set, the member must have fallen off
+// the oplog window, a member that can catch up needs no resync. A member that
+// was already removed by a previous attempt is added back once it is empty,
+// it is wiped again if the previous attempt failed to wipe it.
+func (mgr *Manager) ResyncMember(ctx context.Context, cluster *dcs.Cluster, force bool) (*ResyncResult, error) {
+	member := cluster.GetMemberWithName(mgr.CurrentMemberName)
+	if member == nil {
+		return nil, errors.Errorf("member %s not found", mgr.CurrentMemberName)
+	}
+	host := cluster.GetMemberAddrWithPort(*member)
+
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet client")
+	}
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet status")
+	}
+	rsConfig, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet config")
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, resyncTimeout)
+		defer cancel()
+	}
+
+	inConfig := false
+	for _, configMember := range rsConfig.Members {
+		inConfig = inConfig || configMember.Host == host
+	}
+
+	result := &ResyncResult{Member: mgr.CurrentMemberName}
+	if inConfig {
+		window, err := GetOplogWindow(ctx, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "get oplog window")
+		}
+		status, err := checkResync(rsStatus, rsConfig, host, window, force)
+		if err != nil {
+			return nil, err
+		}
+		result.State = status.StateStr
+		result.LagSecs = optimeLag(rsStatus.Primary().Optime, status.Optime)
+		result.OplogWindowSecs = window.Seconds()
+
+		mgr.Logger.Info("resync member", "member", host, "state", status.StateStr,
+			"lag", result.LagSecs, "oplogWindow", result.OplogWindowSecs)
+		if err = mgr.LeaveMemberFromCluster(ctx, cluster, mgr.CurrentMemberName); err != nil {
+			return nil, errors.Wrap(err, "remove member")
+		}
+		if err = mgr.shutdownAndWipe(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// a previous attempt may have removed the member but failed to wipe its
+	// data, which must not be added back
+	if err = mgr.waitForEmptyMember(ctx, !inConfig); err != nil {
+		return nil, err
+	}
+	if err = mgr.JoinCurrentMemberToCluster(ctx, cluster); err != nil {
+		return nil, errors.Wrap(err, "add member back")
+	}
+	result.Message = "member added back, initial sync started"
+	return result, nil
+}
+
+// checkResync returns the status of the member to resync, or why it can not
+// be resynced.
+func checkResync(rsStatus *ReplSetStatus, rsConfig *RSConfig, host string, window *OplogWindow, force bool) (*Member, error) {
+	primary := rsStatus.Primary()
+	if primary == nil {
+		return nil, errors.New("replica set has no primary")
+	}
+	if primary.Name == host {
+		return nil, errors.Errorf("%s is the primary", host)
+	}
+
+	var status *Member
+	for _, m := range rsStatus.Members {
+		if m != nil && m.Name == host {
+			status = m
+		}
+	}
+	if status == nil {
+		return nil, errors.Errorf("%s is not in the replica set status", host)
+	}
+	if status.State == MemberStateArbiter {
+		return nil, errors.Errorf("%s is an arbiter, it has no data to resync", host)
+	}
+
+	if !force && (status.Optime != nil && !timestampBefore(status.Optime.Timestamp, window.First)) {
+		return nil, errors.Errorf("%s lags %ds behind within the oplog window of %ds, it can catch up without a resync",
+			host, optimeLag(primary.Optime, status.Optime), window.Seconds())
+	}
+
+	voters, healthy := 0, 0
+	for _, configMember := range rsConfig.Members {
+		if configMember.Host == host || !configMember.IsVoter() {
+			continue
+		}
+		voters++
+		for _, m := range rsStatus.Members {
+			if m != nil && m.Name == configMember.Host && m.Health == MemberHealthUp &&
+				(m.State == MemberStatePrimary || m.State == MemberStateSecondary || m.State == MemberStateArbiter) {
+				healthy++
+			}
+		}
+	}
+	if healthy*2 <= voters {
+		return nil, errors.Errorf("only %d of the %d other voting members are healthy, removing %s would lose the majority",
+			healthy, voters, host)
+	}
+	return status, nil
+}
+
+func timestampBefore(a, b primitive.Timestamp) bool {
+	if a.T != b.T {
+		return a.T < b.T
+	}
+	return a.I < b.I
+}
+
+// shutdownAndWait shuts the local mongod down and waits until it no longer
+// answers.
+func (mgr *Manager) shutdownAndWait(ctx context.Context) error {
+	mgr.Logger.Info("shut down mongod to resync")
+	if err := Shutdown(ctx, mgr.GetClient()); err != nil {
+		return errors.Wrap(err, "shutdown")
+	}
+
+	client, err := NewLocalUnauthClient(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, resyncPollInterval)
+		err := client.Ping(pingCtx, readpref.Nearest())
+		cancel()
+		if err != nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for mongod to shut down")
+		case <-time.After(resyncPollInterval):
+		}
+	}
+}
+
+// shutdownAndWipe shuts the local mongod down and wipes its data directory.
+func (mgr *Manager) shutdownAndWipe(ctx context.Context) error {
+	if err := mgr.shutdownAndWait(ctx); err != nil {
+		return err
+	}
+	if err := wipeDataDir(mgr.DataDir); err != nil {
+		return errors.Wrap(err, "wipe data directory")
+	}
+	mgr.Logger.Info("data directory wiped", "dir", mgr.DataDir)
+	return nil
+}
+
+// waitForEmptyMember waits until the local mongod answers again and checks
+// that it started without data. If it still has data and wipe is set, it is
+// shut down and wiped once more, otherwise it is refused.
+func (mgr *Manager) waitForEmptyMember(ctx context.Context, wipe bool) error {
+	if err := mgr.waitForStartup(ctx); err != nil {
+		return err
+	}
+	empty, err := mgr.isLocalMemberEmpty(ctx)
+	if err != nil {
+		return err
+	}
+	if empty {
+		return nil
+	}
+	if !wipe {
+		return errors.New("mongod restarted with data, refuse to add it back")
+	}
+
+	mgr.Logger.Info("mongod still has the data of the removed member, wipe it again")
+	if err = mgr.shutdownAndWipe(ctx); err != nil {
+		return err
+	}
+	if err = mgr.waitForStartup(ctx); err != nil {
+		return err
+	}
+	if empty, err = mgr.isLocalMemberEmpty(ctx); err != nil {
+		return err
+	}
+	if !empty {
+		return errors.New("mongod restarted with data after the wipe, refuse to add it back")
+	}
+	return nil
+}
+
+// isLocalMemberEmpty returns true if the local mongod has neither a replica
+// set config nor users, as after a start on an empty data directory.
+func (mgr *Manager) isLocalMemberEmpty(ctx context.Context) (bool, error) {
+	client, err := NewLocalUnauthClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, err = GetReplSetStatus(ctx, client)
+	return isEmptyMemberStatus(err)
+}
+
+// isEmptyMemberStatus interprets the error of replSetGetStatus without
+// authentication: only an empty mongod has no replica set config, a mongod
+// with users refuses the command.
+func isEmptyMemberStatus(err error) (bool, error) {
+	if err == nil {
+		return false, nil
+	}
+	if cmdErr, ok := errors.Cause(err).(mongo.CommandError); ok {
+		switch cmdErr.Name {
+		case "NotYetInitialized":
+			return true, nil
+		case "Unauthorized":
+			return false, nil
+		}
+	}
+	return false, err
+}
+
+// waitForStartup waits until the local mongod answers again.
+func (mgr *Manager) waitForStartup(ctx context.Context) error {
+	client, err := NewLocalUnauthClient(ctx)
+	if err != nil {
+		return err
+	}
+	for {
+		pingCtx, cancel := context.WithTimeout(ctx, resyncPollInterval)
+		err := client.Ping(pingCtx, readpref.Nearest())
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for mongod to restart")
+		case <-time.After(resyncPollInterval):
+		}
+	}
+}
+
+// wipeDataDir removes the content of the data directory of a stopped
+// mongod. It refuses to touch a directory that is not a dbPath, or whose
+// lock file shows that mongod is still running. The lock file is locked
+// during the wipe, so that a restarting mongod can not open the directory.
+func wipeDataDir(dir string) error {
+	if dir == "" || !filepath.IsAbs(dir) || filepath.Clean(dir) == "/" {
+		return errors.Errorf("refuse to wipe data directory %q", dir)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	isDataDir := false
+	for _, marker := range dataDirMarkers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			isDataDir = true
+		}
+	}
+	if !isDataDir {
+		return errors.Errorf("%s is not a mongod data directory", dir)
+	}
+
+	lockFile, err := lockDataDir(dir)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Close()
+	if info, err := lockFile.Stat(); err == nil && info.Size() > 0 {
+		return errors.Errorf("mongod still holds the lock of %s", dir)
+	}
+
+	// the lock file goes last, it stays locked until it is closed
+	for _, entry := range entries {
+		if entry.Name() == mongodLockFile {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(lockFile.Name())
+}
+
+// lockDataDir takes the exclusive lock mongod takes on the lock file of its
+// data directory, the lock is released when the file is closed.
+func lockDataDir(dir string) (*os.File, error) {
+	lockFile, err := os.OpenFile(filepath.Join(dir, mongodLockFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lockFile.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errors.Errorf("mongod still holds the lock of %s", dir)
+		}
+		return nil, errors.Wrap(err, "lock data directory")
+	}
+	return lockFile, nil
+}
+
+// Shutdown stops the server with force, so that a secondary does not wait
+// for a catch-up. The server closes the connection instead of answering.
+func Shutdown(ctx context.Context, client *mongo.Client) error {
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "shutdown", Value: 1},
+		{Key: "force", Value: true},
+	})
+	if err := res.Err(); err != nil && !mongo.IsNetworkError(err) {
+		return err
+	}
+	return nil
+}
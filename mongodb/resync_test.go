@@ -0,0 +1,110 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestCheckResync(t *testing.T) {
+	newStatus := func() *ReplSetStatus {
+		return &ReplSetStatus{Members: []*Member{
+			newRestartMember("10.0.0.1:27017", MemberStatePrimary, 100, 1000),
+			newRestartMember("10.0.0.2:27017", MemberStateSecondary, 100, 1000),
+			newRestartMember("10.0.0.3:27017", MemberStateRecovering, 100, 100),
+		}}
+	}
+	rsConfig := &RSConfig{Members: ConfigMembers{
+		{ID: 0, Host: "10.0.0.1:27017"},
+		{ID: 1, Host: "10.0.0.2:27017"},
+		{ID: 2, Host: "10.0.0.3:27017"},
+	}}
+	window := &OplogWindow{First: primitive.Timestamp{T: 500}, Last: primitive.Timestamp{T: 1000}}
+
+	status, err := checkResync(newStatus(), rsConfig, "10.0.0.3:27017", window, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.3:27017", status.Name)
+
+	_, err = checkResync(newStatus(), rsConfig, "10.0.0.1:27017", window, true)
+	assert.ErrorContains(t, err, "is the primary")
+
+	_, err = checkResync(newStatus(), rsConfig, "10.0.0.2:27017", window, false)
+	assert.ErrorContains(t, err, "within the oplog window")
+	_, err = checkResync(newStatus(), rsConfig, "10.0.0.2:27017", window, true)
+	assert.ErrorContains(t, err, "lose the majority")
+
+	rsStatus := newStatus()
+	rsStatus.Members[1].Health = MemberHealthDown
+	_, err = checkResync(rsStatus, rsConfig, "10.0.0.3:27017", window, false)
+	assert.ErrorContains(t, err, "lose the majority")
+}
+
+func TestWipeDataDir(t *testing.T) {
+	assert.NotNil(t, wipeDataDir(""))
+	assert.NotNil(t, wipeDataDir("/"))
+	assert.NotNil(t, wipeDataDir("data"))
+
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0600))
+	assert.ErrorContains(t, wipeDataDir(dir), "not a mongod data directory")
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "WiredTiger"), []byte("WiredTiger"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "mongod.lock"), []byte("1"), 0600))
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "journal"), 0700))
+	assert.ErrorContains(t, wipeDataDir(dir), "still holds the lock")
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "mongod.lock"), nil, 0600))
+	lockFile, err := os.Open(filepath.Join(dir, "mongod.lock"))
+	assert.Nil(t, err)
+	assert.Nil(t, syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB))
+	assert.ErrorContains(t, wipeDataDir(dir), "still holds the lock")
+	assert.FileExists(t, filepath.Join(dir, "WiredTiger"))
+	assert.Nil(t, lockFile.Close())
+
+	assert.Nil(t, wipeDataDir(dir))
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestIsEmptyMemberStatus(t *testing.T) {
+	empty, err := isEmptyMemberStatus(errors.Wrap(mongo.CommandError{Code: 94, Name: "NotYetInitialized"}, "replSetGetStatus"))
+	assert.Nil(t, err)
+	assert.True(t, empty)
+
+	// the data of the removed member has its users and its config
+	empty, err = isEmptyMemberStatus(errors.Wrap(mongo.CommandError{Code: 13, Name: "Unauthorized"}, "replSetGetStatus"))
+	assert.Nil(t, err)
+	assert.False(t, empty)
+	empty, err = isEmptyMemberStatus(nil)
+	assert.Nil(t, err)
+	assert.False(t, empty)
+
+	_, err = isEmptyMemberStatus(errors.New("connection refused"))
+	assert.NotNil(t, err)
+}
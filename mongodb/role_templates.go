@@ -0,0 +1,248 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// RoleTemplate declares a custom role of the admin database.
+type RoleTemplate struct {
+	Role       string                   `json:"role"`
+	Privileges []RolePrivilege          `json:"privileges"`
+	Roles      []map[string]interface{} `json:"roles"`
+}
+
+type RoleTemplates struct {
+	Roles []RoleTemplate `json:"roles"`
+}
+
+const (
+	RoleInSync = "in-sync"
+	RoleCreate = "create"
+	RoleUpdate = "update"
+	RoleDrop   = "drop"
+)
+
+// RoleDrift is how a role differs from its template and the action taken to
+// converge it.
+type RoleDrift struct {
+	Role   string
+	Action string
+	Detail string
+	Error  string
+}
+
+// LoadRoleTemplates reads the role templates from a YAML or JSON file.
+func LoadRoleTemplates(path string) ([]RoleTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read role templates")
+	}
+
+	templates := RoleTemplates{}
+	if err = yaml.Unmarshal(data, &templates); err != nil {
+		return nil, errors.Wrap(err, "parse role templates")
+	}
+
+	names := map[string]bool{}
+	for _, template := range templates.Roles {
+		if template.Role == "" {
+			return nil, errors.New("role template without role name")
+		}
+		if names[template.Role] {
+			return nil, errors.Errorf("role %s is declared more than once", template.Role)
+		}
+		names[template.Role] = true
+	}
+	return templates.Roles, nil
+}
+
+// ReconcileRoles creates, updates and drops roles on the primary until they
+// match the templates. Only the managed roles, the ones created from earlier
+// templates, are dropped. With dryRun it only reports the drift. It returns
+// the drift of each role and the roles managed afterwards.
+func (mgr *Manager) ReconcileRoles(ctx context.Context, cluster *dcs.Cluster, templates []RoleTemplate, managed []string, dryRun bool) ([]RoleDrift, []string, error) {
+	client, err := mgr.GetLeaderClient(ctx, cluster)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get leader client")
+	}
+
+	existing := map[string]*Role{}
+	for _, name := range roleNames(templates, managed) {
+		role, err := GetRole(ctx, client, name)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "get role %s", name)
+		}
+		if role != nil {
+			existing[name] = role
+		}
+	}
+
+	drifts := planRoles(templates, existing, managed)
+	if dryRun {
+		return drifts, managed, nil
+	}
+
+	newManaged := []string{}
+	for i := range drifts {
+		drift := &drifts[i]
+		template := findRoleTemplate(templates, drift.Role)
+		switch drift.Action {
+		case RoleCreate:
+			err = CreateRole(ctx, client, drift.Role, template.Privileges, inheritedRoles(template))
+		case RoleUpdate:
+			err = UpdateRole(ctx, client, drift.Role, template.Privileges, inheritedRoles(template))
+		case RoleDrop:
+			err = DropRole(ctx, client, drift.Role)
+		default:
+			err = nil
+		}
+		if err != nil {
+			drift.Error = err.Error()
+		}
+
+		// keep the role managed until it is dropped
+		if template != nil || (drift.Action == RoleDrop && err != nil) {
+			newManaged = append(newManaged, drift.Role)
+		}
+	}
+	return drifts, newManaged, nil
+}
+
+// planRoles compares the templates with the existing roles.
+func planRoles(templates []RoleTemplate, existing map[string]*Role, managed []string) []RoleDrift {
+	drifts := []RoleDrift{}
+	for i := range templates {
+		template := &templates[i]
+		role, ok := existing[template.Role]
+		if !ok {
+			drifts = append(drifts, RoleDrift{Role: template.Role, Action: RoleCreate, Detail: "role not exists"})
+			continue
+		}
+
+		detail := diffRole(template, role)
+		if detail == "" {
+			drifts = append(drifts, RoleDrift{Role: template.Role, Action: RoleInSync})
+			continue
+		}
+		drifts = append(drifts, RoleDrift{Role: template.Role, Action: RoleUpdate, Detail: detail})
+	}
+
+	for _, name := range managed {
+		if findRoleTemplate(templates, name) != nil {
+			continue
+		}
+		if _, ok := existing[name]; !ok {
+			continue
+		}
+		drifts = append(drifts, RoleDrift{Role: name, Action: RoleDrop, Detail: "role removed from templates"})
+	}
+	return drifts
+}
+
+// diffRole describes how the role differs from the template, it is empty if
+// they match.
+func diffRole(template *RoleTemplate, role *Role) string {
+	var diffs []string
+	if !equalStrings(normalizePrivileges(template.Privileges), normalizePrivileges(role.Privileges)) {
+		diffs = append(diffs, "privileges differ")
+	}
+	if !equalStrings(normalizeRoles(template.Roles), normalizeRoles(role.Roles)) {
+		diffs = append(diffs, "inherited roles differ")
+	}
+	if len(diffs) == 0 {
+		return ""
+	}
+	return strings.Join(diffs, ", ")
+}
+
+func normalizePrivileges(privileges []RolePrivilege) []string {
+	normalized := make([]string, 0, len(privileges))
+	for _, privilege := range privileges {
+		actions := append([]string{}, privilege.Actions...)
+		sort.Strings(actions)
+		resource, _ := json.Marshal(privilege.Resource)
+		normalized = append(normalized, fmt.Sprintf("%s%v", resource, actions))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func normalizeRoles(roles []map[string]interface{}) []string {
+	normalized := make([]string, 0, len(roles))
+	for _, role := range roles {
+		normalized = append(normalized, fmt.Sprintf("%v.%v", role["db"], role["role"]))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func findRoleTemplate(templates []RoleTemplate, name string) *RoleTemplate {
+	for i := range templates {
+		if templates[i].Role == name {
+			return &templates[i]
+		}
+	}
+	return nil
+}
+
+func roleNames(templates []RoleTemplate, managed []string) []string {
+	var names []string
+	for _, template := range templates {
+		names = append(names, template.Role)
+	}
+	for _, name := range managed {
+		if findRoleTemplate(templates, name) == nil {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func inheritedRoles(template *RoleTemplate) []interface{} {
+	roles := make([]interface{}, 0, len(template.Roles))
+	for _, role := range template.Roles {
+		roles = append(roles, role)
+	}
+	return roles
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const roleTemplatesFixture = `
+roles:
+- role: app-read
+  privileges:
+  - resource: {db: app, collection: ""}
+    actions: [find, listCollections]
+- role: app-write
+  privileges:
+  - resource: {db: app, collection: orders}
+    actions: [update, insert, find]
+  roles:
+  - {role: app-read, db: admin}
+`
+
+func TestLoadRoleTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roles.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte(roleTemplatesFixture), 0600))
+
+	templates, err := LoadRoleTemplates(path)
+	assert.Nil(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, "app-write", templates[1].Role)
+	assert.Equal(t, "orders", templates[1].Privileges[0].Resource["collection"])
+	assert.Equal(t, "app-read", templates[1].Roles[0]["role"])
+
+	assert.Nil(t, os.WriteFile(path, []byte("roles:\n- role: a\n- role: a\n"), 0600))
+	_, err = LoadRoleTemplates(path)
+	assert.EqualError(t, err, "role a is declared more than once")
+}
+
+func TestPlanRoles(t *testing.T) {
+	templates := []RoleTemplate{
+		{
+			Role: "app-read",
+			Privileges: []RolePrivilege{
+				{Resource: map[string]interface{}{"db": "app", "collection": ""}, Actions: []string{"find", "listCollections"}},
+			},
+		},
+		{
+			Role: "app-write",
+			Privileges: []RolePrivilege{
+				{Resource: map[string]interface{}{"db": "app", "collection": "orders"}, Actions: []string{"insert"}},
+			},
+		},
+		{Role: "app-admin"},
+	}
+	existing := map[string]*Role{
+		// same privileges in another order
+		"app-read": {
+			Role: "app-read",
+			DB:   "admin",
+			Privileges: []RolePrivilege{
+				{Resource: map[string]interface{}{"collection": "", "db": "app"}, Actions: []string{"listCollections", "find"}},
+			},
+		},
+		"app-write": {
+			Role:  "app-write",
+			DB:    "admin",
+			Roles: []map[string]interface{}{{"role": "app-read", "db": "admin"}},
+			Privileges: []RolePrivilege{
+				{Resource: map[string]interface{}{"db": "app", "collection": "orders"}, Actions: []string{"insert", "remove"}},
+			},
+		},
+		"legacy": {Role: "legacy", DB: "admin"},
+	}
+
+	drifts := planRoles(templates, existing, []string{"app-read", "legacy", "gone"})
+	assert.Equal(t, []RoleDrift{
+		{Role: "app-read", Action: RoleInSync},
+		{Role: "app-write", Action: RoleUpdate, Detail: "privileges differ, inherited roles differ"},
+		{Role: "app-admin", Action: RoleCreate, Detail: "role not exists"},
+		{Role: "legacy", Action: RoleDrop, Detail: "role removed from templates"},
+	}, drifts)
+}
@@ -122,3 +122,52 @@ func GetRole(ctx context.Context, client *mongo.Client, role string) (*Role, err
 	}
 	return &resp.Roles[0], nil
 }
+
+// GetRolesInfo returns the roles with their privileges, including the ones
+// inherited from the roles they are granted.
+func GetRolesInfo(ctx context.Context, client *mongo.Client, roles []map[string]interface{}) ([]Role, error) {
+	resp := RoleInfo{}
+
+	rolesArr := bson.A{}
+	for _, r := range roles {
+		rolesArr = append(rolesArr, r)
+	}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "rolesInfo", Value: rolesArr},
+		{Key: "showPrivileges", Value: true},
+		{Key: "showBuiltinRoles", Value: true},
+	})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "run command")
+	}
+
+	err := res.Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode response")
+	}
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+	return resp.Roles, nil
+}
+
+func DropRole(ctx context.Context, client *mongo.Client, role string) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "dropRole", Value: role}})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "failed to drop role")
+	}
+
+	err := res.Decode(&resp)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// Status of a step of a rolling restart. Only one step is actionable at a
+// time, the ones after it are pending.
+const (
+	RestartStepPending    = "pending"
+	RestartStepRestart    = "restart"
+	RestartStepStepDown   = "stepdown"
+	RestartStepRecovering = "recovering"
+	RestartStepDone       = "done"
+)
+
+// DefaultRestartMaxLag is how many seconds a restarted secondary may lag
+// behind the primary before the next member is restarted.
+const DefaultRestartMaxLag = 10
+
+// RestartStep is the restart of a member. Restart means the member is to be
+// restarted now, recovering that it was restarted but is not back as a
+// secondary with an acceptable lag yet.
+type RestartStep struct {
+	Member  string
+	Host    string
+	State   string
+	LagSecs int64
+	Status  string
+	Message string
+}
+
+// RestartPlan is the progress of a rolling restart.
+type RestartPlan struct {
+	Steps     []RestartStep
+	Completed bool
+}
+
+// PlanRollingRestart returns the order in which to restart the members: the
+// secondaries and arbiters first, the primary last.
+func (mgr *Manager) PlanRollingRestart(ctx context.Context, cluster *dcs.Cluster) ([]string, error) {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet client")
+	}
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet status")
+	}
+	return planRollingRestart(cluster, rsStatus)
+}
+
+func planRollingRestart(cluster *dcs.Cluster, rsStatus *ReplSetStatus) ([]string, error) {
+	primary := rsStatus.Primary()
+	if primary == nil {
+		return nil, errors.New("replica set has no primary")
+	}
+
+	var order []string
+	var primaryName string
+	for _, member := range cluster.Members {
+		if cluster.GetMemberAddrWithPort(member) == primary.Name {
+			primaryName = member.Name
+			continue
+		}
+		order = append(order, member.Name)
+	}
+	if primaryName == "" {
+		return nil, errors.Errorf("primary %s is not a member of the cluster", primary.Name)
+	}
+	sort.Strings(order)
+	return append(order, primaryName), nil
+}
+
+// RollingRestart reports the progress of the rolling restart, and steps the
+// primary down once it is the only member left to restart.
+func (mgr *Manager) RollingRestart(ctx context.Context, cluster *dcs.Cluster, record *dcs.RollingRestartRecord, maxLag int64) (*RestartPlan, error) {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet client")
+	}
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return nil, errors.Wrap(err, "get replSet status")
+	}
+
+	plan := evaluateRollingRestart(cluster, rsStatus, record, maxLag)
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if step.Status != RestartStepStepDown {
+			continue
+		}
+		mgr.Logger.Info("step down primary before restarting it", "member", step.Member)
+		if err = mgr.Switchover(ctx, cluster, step.Member, ""); err != nil {
+			step.Message = err.Error()
+			break
+		}
+		step.Status = RestartStepRestart
+		step.Message = "stepped down"
+	}
+	return plan, nil
+}
+
+// evaluateRollingRestart computes the status of each step from the replica
+// set status. A member was restarted if it has been up for less time than
+// the rolling restart is running.
+func evaluateRollingRestart(cluster *dcs.Cluster, rsStatus *ReplSetStatus, record *dcs.RollingRestartRecord, maxLag int64) *RestartPlan {
+	latest := latestOptime(rsStatus)
+	elapsed := rsStatus.Date.Unix() - record.StartTime
+
+	plan := &RestartPlan{Completed: true}
+	blocked := false
+	for _, name := range record.Members {
+		step := RestartStep{Member: name}
+		member := cluster.GetMemberWithName(name)
+		var status *Member
+		if member != nil {
+			step.Host = cluster.GetMemberAddrWithPort(*member)
+			for _, m := range rsStatus.Members {
+				if m != nil && m.Name == step.Host {
+					status = m
+					break
+				}
+			}
+		}
+
+		switch {
+		case member == nil:
+			step.Status = RestartStepDone
+			step.Message = "no longer a member"
+		case status == nil:
+			step.Status = RestartStepRecovering
+			step.Message = "not in the replica set status"
+		case status.Uptime < elapsed:
+			step.State = status.StateStr
+			if status.State != MemberStateArbiter {
+				step.LagSecs = optimeLag(latest, status.Optime)
+			}
+			step.Status = RestartStepDone
+			if status.Health != MemberHealthUp {
+				step.Status = RestartStepRecovering
+				step.Message = "member is down"
+			} else if status.State != MemberStateSecondary && status.State != MemberStateArbiter && status.State != MemberStatePrimary {
+				step.Status = RestartStepRecovering
+				step.Message = "member is " + status.StateStr
+			} else if step.LagSecs > maxLag {
+				step.Status = RestartStepRecovering
+				step.Message = "member is catching up"
+			}
+		default:
+			step.State = status.StateStr
+			switch {
+			case blocked:
+				step.Status = RestartStepPending
+			case status.State == MemberStatePrimary:
+				step.Status = RestartStepStepDown
+			default:
+				step.Status = RestartStepRestart
+			}
+		}
+
+		if step.Status != RestartStepDone {
+			blocked = true
+			plan.Completed = false
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func newRestartCluster() *dcs.Cluster {
+	return &dcs.Cluster{
+		Members: []dcs.Member{
+			{Name: "pod-0", PodIP: "10.0.0.1", DBPort: "27017", UseIP: true},
+			{Name: "pod-1", PodIP: "10.0.0.2", DBPort: "27017", UseIP: true},
+			{Name: "pod-2", PodIP: "10.0.0.3", DBPort: "27017", UseIP: true},
+		},
+	}
+}
+
+func newRestartMember(name string, state MemberState, uptime int64, optime uint32) *Member {
+	return &Member{
+		Name:     name,
+		Health:   MemberHealthUp,
+		State:    state,
+		StateStr: MemberStateStrings[state],
+		Uptime:   uptime,
+		Optime:   &Optime{Timestamp: primitive.Timestamp{T: optime}, Term: 1},
+	}
+}
+
+func TestPlanRollingRestart(t *testing.T) {
+	cluster := newRestartCluster()
+	status := &ReplSetStatus{Members: []*Member{
+		newRestartMember("10.0.0.1:27017", MemberStatePrimary, 100, 100),
+		newRestartMember("10.0.0.2:27017", MemberStateSecondary, 100, 100),
+		newRestartMember("10.0.0.3:27017", MemberStateSecondary, 100, 100),
+	}}
+
+	order, err := planRollingRestart(cluster, status)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"pod-1", "pod-2", "pod-0"}, order)
+
+	status.Members[0].State = MemberStateSecondary
+	_, err = planRollingRestart(cluster, status)
+	assert.NotNil(t, err)
+}
+
+func TestEvaluateRollingRestart(t *testing.T) {
+	cluster := newRestartCluster()
+	now := time.Unix(1000, 0)
+	record := &dcs.RollingRestartRecord{Members: []string{"pod-1", "pod-2", "pod-0"}, StartTime: 900}
+	status := &ReplSetStatus{Date: now, Members: []*Member{
+		newRestartMember("10.0.0.1:27017", MemberStatePrimary, 5000, 1000),
+		newRestartMember("10.0.0.2:27017", MemberStateSecondary, 5000, 1000),
+		newRestartMember("10.0.0.3:27017", MemberStateSecondary, 5000, 1000),
+	}}
+	statuses := func(plan *RestartPlan) []string {
+		var result []string
+		for _, step := range plan.Steps {
+			result = append(result, step.Status)
+		}
+		return result
+	}
+
+	plan := evaluateRollingRestart(cluster, status, record, 10)
+	assert.False(t, plan.Completed)
+	assert.Equal(t, []string{RestartStepRestart, RestartStepPending, RestartStepPending}, statuses(plan))
+
+	status.Members[1] = newRestartMember("10.0.0.2:27017", MemberStateSecondary, 30, 950)
+	plan = evaluateRollingRestart(cluster, status, record, 10)
+	assert.Equal(t, []string{RestartStepRecovering, RestartStepPending, RestartStepPending}, statuses(plan))
+	assert.Equal(t, int64(50), plan.Steps[0].LagSecs)
+
+	status.Members[1].Optime.Timestamp.T = 995
+	status.Members[2] = newRestartMember("10.0.0.3:27017", MemberStateSecondary, 20, 1000)
+	plan = evaluateRollingRestart(cluster, status, record, 10)
+	assert.Equal(t, []string{RestartStepDone, RestartStepDone, RestartStepStepDown}, statuses(plan))
+
+	status.Members[0] = newRestartMember("10.0.0.1:27017", MemberStateSecondary, 5000, 1000)
+	status.Members[1].State = MemberStatePrimary
+	plan = evaluateRollingRestart(cluster, status, record, 10)
+	assert.Equal(t, []string{RestartStepDone, RestartStepDone, RestartStepRestart}, statuses(plan))
+
+	status.Members[0].Uptime = 10
+	plan = evaluateRollingRestart(cluster, status, record, 10)
+	assert.True(t, plan.Completed)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+// shardRemovalTimeout bounds a step of the shard removal if the caller set no
+// deadline, movePrimary copies the unsharded collections of the database and
+// would not finish within the operation timeout of the clients.
+const shardRemovalTimeout = 30 * time.Minute
+
+// ShardRemoval is the progress of a shard being drained out of the sharded
+// cluster.
+type ShardRemoval struct {
+	Shard string
+	// State is one of started, ongoing and completed.
+	State           string
+	RemainingChunks int
+	JumboChunks     int
+	// DBsToMove are the databases whose primary shard is still the removed
+	// one.
+	DBsToMove []string
+	Message   string
+}
+
+// GetMongosClient returns a client of the mongos routers of the sharded
+// cluster, or of the local mongos if none are configured, which is the case
+// of the plugin serving a mongos component.
+func (mgr *Manager) GetMongosClient(ctx context.Context) (*mongo.Client, error) {
+	config := GetConfig()
+	hosts := config.MongosHosts
+	if len(hosts) == 0 {
+		hosts = config.Hosts
+	}
+	return NewMongosClient(ctx, hosts)
+}
+
+// RegisterShard adds the replica set of the component to the sharded cluster
+// if it is not a shard yet, and returns true if it added it. The shard is
+// named after the replica set.
+func (mgr *Manager) RegisterShard(ctx context.Context, cluster *dcs.Cluster) (bool, error) {
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	shards, err := ListShards(ctx, client)
+	if err != nil {
+		return false, err
+	}
+
+	name := GetConfig().ReplSetName
+	for _, shard := range shards.Shards {
+		if shard.ID == name {
+			return false, nil
+		}
+	}
+
+	connStr := shardConnectionString(name, cluster)
+	mgr.Logger.Info("add shard", "shard", name, "hosts", connStr)
+	if err = AddShard(ctx, client, name, connStr); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveShard runs one step of draining the shard out of the sharded
+// cluster. The first call starts the draining, and once the balancer has
+// migrated all the chunks, the databases whose primary shard it is are moved
+// to another shard. It is called again until the removal is completed.
+func (mgr *Manager) RemoveShard(ctx context.Context, shard string) (*ShardRemoval, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, shardRemovalTimeout)
+		defer cancel()
+	}
+
+	client, err := mgr.GetMongosClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := RemoveShard(ctx, client, shard)
+	if err != nil {
+		return nil, err
+	}
+
+	removal := newShardRemoval(shard, resp)
+	if removal.State != ShardRemoveOngoing || removal.RemainingChunks > 0 || len(removal.DBsToMove) == 0 {
+		return removal, nil
+	}
+
+	shards, err := ListShards(ctx, client)
+	if err != nil {
+		return removal, err
+	}
+	target, err := pickPrimaryShard(shards, shard)
+	if err != nil {
+		return removal, err
+	}
+	for _, db := range removal.DBsToMove {
+		mgr.Logger.Info("move primary", "database", db, "from", shard, "to", target)
+		if err = MovePrimary(ctx, client, db, target); err != nil {
+			return removal, errors.Wrapf(err, "move primary of %s", db)
+		}
+	}
+	removal.Message = fmt.Sprintf("moved the primary of %s to %s", strings.Join(removal.DBsToMove, ", "), target)
+	return removal, nil
+}
+
+func newShardRemoval(shard string, resp *ShardRemoveResp) *ShardRemoval {
+	removal := &ShardRemoval{
+		Shard:           shard,
+		State:           resp.State,
+		RemainingChunks: resp.Remaining.Chunks,
+		JumboChunks:     resp.Remaining.JumboChunks,
+		DBsToMove:       resp.DBsToMove,
+		Message:         resp.Msg,
+	}
+	// the balancer does not migrate jumbo chunks, the draining would never end
+	if removal.RemainingChunks > 0 && removal.RemainingChunks == removal.JumboChunks {
+		removal.Message = fmt.Sprintf("%d jumbo chunks are left, split them or clear their jumbo flag to finish draining", removal.JumboChunks)
+	}
+	return removal
+}
+
+// pickPrimaryShard returns the shard to move the databases of the removed
+// shard to, the first one by name that is not draining.
+func pickPrimaryShard(shards *ShardList, removed string) (string, error) {
+	var names []string
+	for _, shard := range shards.Shards {
+		if shard.ID != removed && !shard.Draining {
+			names = append(names, shard.ID)
+		}
+	}
+	if len(names) == 0 {
+		return "", errors.Errorf("no shard left to move the databases of %s to", removed)
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+// shardConnectionString returns the replica set connection string addShard
+// takes, with the members that hold data as seeds.
+func shardConnectionString(name string, cluster *dcs.Cluster) string {
+	var hosts []string
+	for _, member := range cluster.Members {
+		if !member.IsArbiter {
+			hosts = append(hosts, cluster.GetMemberAddrWithPort(member))
+		}
+	}
+	return name + "/" + strings.Join(hosts, ",")
+}
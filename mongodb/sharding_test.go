@@ -0,0 +1,88 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func TestNewShardRemoval(t *testing.T) {
+	t.Run("decodes the databases to move", func(t *testing.T) {
+		raw, err := bson.Marshal(bson.M{
+			"msg":       "draining ongoing",
+			"state":     ShardRemoveOngoing,
+			"remaining": bson.M{"chunks": 0, "jumboChunks": 0, "dbs": 2},
+			"dbsToMove": bson.A{"app", "audit"},
+			"ok":        1,
+		})
+		assert.Nil(t, err)
+		resp := &ShardRemoveResp{}
+		assert.Nil(t, bson.Unmarshal(raw, resp))
+
+		removal := newShardRemoval("shard-1", resp)
+		assert.Equal(t, ShardRemoveOngoing, removal.State)
+		assert.Equal(t, []string{"app", "audit"}, removal.DBsToMove)
+		assert.Equal(t, "draining ongoing", removal.Message)
+	})
+
+	t.Run("only jumbo chunks left", func(t *testing.T) {
+		resp := &ShardRemoveResp{State: ShardRemoveOngoing}
+		resp.Remaining.Chunks = 3
+		resp.Remaining.JumboChunks = 3
+
+		removal := newShardRemoval("shard-1", resp)
+		assert.Equal(t, "3 jumbo chunks are left, split them or clear their jumbo flag to finish draining", removal.Message)
+	})
+}
+
+func TestPickPrimaryShard(t *testing.T) {
+	shards := &ShardList{}
+	raw, err := bson.Marshal(bson.M{"shards": bson.A{
+		bson.M{"_id": "shard-2", "host": "shard-2/pod-0:27017"},
+		bson.M{"_id": "shard-1", "host": "shard-1/pod-0:27017"},
+		bson.M{"_id": "shard-0", "host": "shard-0/pod-0:27017", "draining": true},
+	}})
+	assert.Nil(t, err)
+	assert.Nil(t, bson.Unmarshal(raw, shards))
+
+	target, err := pickPrimaryShard(shards, "shard-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "shard-2", target)
+
+	shards.Shards = shards.Shards[1:]
+	_, err = pickPrimaryShard(shards, "shard-1")
+	assert.EqualError(t, err, "no shard left to move the databases of shard-1 to")
+}
+
+func TestShardConnectionString(t *testing.T) {
+	cluster := &dcs.Cluster{
+		Members: []dcs.Member{
+			{Name: "pod-0", PodIP: "10.0.0.1", DBPort: "27017", UseIP: true},
+			{Name: "pod-1", PodIP: "10.0.0.2", DBPort: "27017", UseIP: true, IsArbiter: true},
+			{Name: "pod-2", PodIP: "10.0.0.3", DBPort: "27017", UseIP: true},
+		},
+	}
+	assert.Equal(t, "rs0/10.0.0.1:27017,10.0.0.3:27017", shardConnectionString("rs0", cluster))
+}
@@ -0,0 +1,118 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ListShards returns the shards of the sharded cluster, the client MUST be
+// connected to mongos.
+func ListShards(ctx context.Context, client *mongo.Client) (*ShardList, error) {
+	resp := &ShardList{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "listShards", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "listShards")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode listShards response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+// AddShard adds the replica set reachable with the connection string, like
+// rs0/host1:27017,host2:27017, to the sharded cluster as the named shard.
+func AddShard(ctx context.Context, client *mongo.Client, name, connStr string) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "addShard", Value: connStr},
+		{Key: "name", Value: name},
+	})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "addShard")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode addShard response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
+
+// RemoveShard starts draining the shard, or reports the progress of the
+// draining once it is started.
+func RemoveShard(ctx context.Context, client *mongo.Client, name string) (*ShardRemoveResp, error) {
+	resp := &ShardRemoveResp{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "removeShard", Value: name}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "removeShard")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode removeShard response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
+
+// MovePrimary makes the shard the primary shard of the database, which holds
+// its unsharded collections.
+func MovePrimary(ctx context.Context, client *mongo.Client, db, shard string) error {
+	resp := OKResponse{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{
+		{Key: "movePrimary", Value: db},
+		{Key: "to", Value: shard},
+	})
+	if res.Err() != nil {
+		return errors.Wrap(res.Err(), "movePrimary")
+	}
+
+	if err := res.Decode(&resp); err != nil {
+		return errors.Wrap(err, "failed to decode movePrimary response")
+	}
+
+	if resp.OK != 1 {
+		return errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+const switchoverPollInterval = time.Second
+
+// Switchover hands the primary role over to the candidate, or to the closest
+// electable secondary if no candidate is given. The candidate gets the highest
+// priority, the other secondaries are frozen and the primary steps down once
+// the candidate has caught up. If the candidate does not become primary, the
+// original priorities are restored.
+func (mgr *Manager) Switchover(ctx context.Context, cluster *dcs.Cluster, primary, candidate string) error {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return errors.Wrap(err, "get replSet client")
+	}
+
+	rsStatus, err := GetReplSetStatus(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get replSet status")
+	}
+	rsConfig, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return errors.Wrap(err, "get replSet config")
+	}
+
+	primaryStatus := rsStatus.Primary()
+	if primaryStatus == nil {
+		return errors.New("replica set has no primary")
+	}
+	if primary != "" {
+		primaryMember := cluster.GetMemberWithHost(primaryStatus.Name)
+		if primaryMember == nil || primaryMember.Name != primary {
+			return errors.Errorf("%s is not the primary, current primary is %s", primary, primaryStatus.Name)
+		}
+	}
+
+	candidateStatus, err := mgr.pickSwitchoverCandidate(cluster, rsStatus, rsConfig, candidate)
+	if err != nil {
+		return err
+	}
+	mgr.Logger.Info("switchover", "primary", primaryStatus.Name, "candidate", candidateStatus.Name)
+
+	newConfig := raiseCandidatePriority(rsConfig, candidateStatus.ID)
+	if err = mgr.Reconfigure(ctx, client, newConfig); err != nil {
+		return errors.Wrap(err, "raise candidate priority")
+	}
+
+	frozen := mgr.freezeSecondaries(ctx, rsStatus, rsConfig, candidateStatus)
+	defer mgr.unfreezeMembers(ctx, frozen)
+
+	err = mgr.stepDownAndWait(ctx, cluster, primaryStatus.Name, candidateStatus.Name)
+	if err == nil {
+		mgr.Logger.Info("switchover succeeded", "primary", candidateStatus.Name)
+		return nil
+	}
+
+	mgr.Logger.Info("switchover failed, roll back priorities", "error", err.Error())
+	if rerr := mgr.restorePriorities(ctx, cluster, rsConfig); rerr != nil {
+		mgr.Logger.Info("roll back priorities failed", "error", rerr.Error())
+		return errors.Wrapf(err, "roll back priorities failed: %v", rerr)
+	}
+	return err
+}
+
+// raiseCandidatePriority returns a copy of the config in which the candidate
+// has the primary priority, and no other member has it.
+func raiseCandidatePriority(rsConfig *RSConfig, candidateID int) *RSConfig {
+	newConfig := rsConfig.DeepCopy()
+	for i := range newConfig.Members {
+		member := &newConfig.Members[i]
+		if member.ID == candidateID {
+			member.Priority = PrimaryPriority
+		} else if member.Priority == PrimaryPriority {
+			member.Priority = SecondaryPriority
+		}
+	}
+	return newConfig
+}
+
+func (mgr *Manager) pickSwitchoverCandidate(cluster *dcs.Cluster, rsStatus *ReplSetStatus, rsConfig *RSConfig, candidate string) (*Member, error) {
+	var maxLag int64
+	if cluster.HaConfig != nil {
+		maxLag = cluster.HaConfig.GetMaxLagOnSwitchover()
+	}
+
+	candidates, rejections := RankCandidates(rsStatus, rsConfig, maxLag)
+	for _, c := range candidates {
+		if c.Status.State == MemberStatePrimary {
+			continue
+		}
+		if candidate == "" {
+			return c.Status, nil
+		}
+		if m := cluster.GetMemberWithHost(c.Status.Name); m != nil && m.Name == candidate {
+			return c.Status, nil
+		}
+	}
+
+	if candidate == "" {
+		return nil, &NoCandidateError{Rejections: rejections}
+	}
+	var candidateRejections []CandidateRejection
+	for _, r := range rejections {
+		if m := cluster.GetMemberWithHost(r.Member); m != nil && m.Name == candidate {
+			candidateRejections = append(candidateRejections, r)
+		}
+	}
+	if len(candidateRejections) == 0 {
+		candidateRejections = []CandidateRejection{{Member: candidate, Reason: "not an electable secondary"}}
+	}
+	return nil, &NoCandidateError{Candidate: candidate, Rejections: candidateRejections}
+}
+
+// freezeSecondaries keeps the healthy electable secondaries other than the
+// candidate from running for election while the primary steps down.
+func (mgr *Manager) freezeSecondaries(ctx context.Context, rsStatus *ReplSetStatus, rsConfig *RSConfig, candidate *Member) []string {
+	electable := map[int]bool{}
+	for i := range rsConfig.Members {
+		electable[rsConfig.Members[i].ID] = rsConfig.Members[i].ineligibleReason() == ""
+	}
+
+	var frozen []string
+	for _, member := range rsStatus.Members {
+		if member.ID == candidate.ID || member.State != MemberStateSecondary ||
+			member.Health != MemberHealthUp || !electable[member.ID] {
+			continue
+		}
+		err := mgr.freezeMember(ctx, member.Name, GetConfig().StepDownSecs)
+		if err != nil {
+			mgr.Logger.Info("freeze member failed", "member", member.Name, "error", err.Error())
+			continue
+		}
+		frozen = append(frozen, member.Name)
+	}
+	return frozen
+}
+
+func (mgr *Manager) unfreezeMembers(ctx context.Context, hosts []string) {
+	for _, host := range hosts {
+		if err := mgr.freezeMember(ctx, host, 0); err != nil {
+			mgr.Logger.Info("unfreeze member failed", "member", host, "error", err.Error())
+		}
+	}
+}
+
+func (mgr *Manager) freezeMember(ctx context.Context, host string, secs int) error {
+	client, err := NewStandaloneClient(ctx, host)
+	if err != nil {
+		return err
+	}
+
+	return Freeze(ctx, client, secs)
+}
+
+// stepDownAndWait steps down the primary and waits for the candidate to be
+// elected.
+func (mgr *Manager) stepDownAndWait(ctx context.Context, cluster *dcs.Cluster, primary, candidate string) error {
+	client, err := NewStandaloneClient(ctx, primary)
+	if err != nil {
+		return errors.Wrap(err, "connect to primary")
+	}
+
+	config := GetConfig()
+	stepDownTimeout := time.Duration(config.StepDownSecs) * time.Second
+	stepDownCtx, cancel := context.WithTimeout(ctx, stepDownTimeout)
+	defer cancel()
+	err = StepDown(stepDownCtx, client, config.StepDownSecs, config.SecondaryCatchUpPeriodSecs)
+	// servers before 4.2 close all connections on step down, and the candidate
+	// may already have taken over due to its higher priority
+	if err != nil && !mongo.IsNetworkError(errors.Cause(err)) {
+		if newPrimary, perr := mgr.getPrimaryHost(ctx, cluster); perr == nil && newPrimary == candidate {
+			return nil
+		}
+		return errors.Wrap(err, "step down primary")
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, stepDownTimeout)
+	defer waitCancel()
+	for {
+		newPrimary, err := mgr.getPrimaryHost(waitCtx, cluster)
+		if err == nil && newPrimary == candidate {
+			return nil
+		}
+		if err == nil && newPrimary != "" && newPrimary != primary {
+			return errors.Errorf("%s became primary instead of %s", newPrimary, candidate)
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return fmt.Errorf("timed out waiting for %s to become primary", candidate)
+		case <-time.After(switchoverPollInterval):
+		}
+	}
+}
+
+func (mgr *Manager) getPrimaryHost(ctx context.Context, cluster *dcs.Cluster) (string, error) {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return "", err
+	}
+
+	pollCtx, cancel := context.WithTimeout(ctx, switchoverPollInterval)
+	defer cancel()
+	rsStatus, err := GetReplSetStatus(pollCtx, client)
+	if err != nil {
+		return "", err
+	}
+	primary := rsStatus.Primary()
+	if primary == nil {
+		return "", nil
+	}
+	return primary.Name, nil
+}
+
+// restorePriorities resets the member priorities to the ones in original.
+func (mgr *Manager) restorePriorities(ctx context.Context, cluster *dcs.Cluster, original *RSConfig) error {
+	client, err := mgr.GetReplSetClient(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	rsConfig, err := GetReplSetConfig(ctx, client)
+	if err != nil {
+		return err
+	}
+	return mgr.Reconfigure(ctx, client, restoredPriorities(rsConfig, original))
+}
+
+// restoredPriorities returns a copy of the config with the priorities of the
+// members in original. Members added since keep their priority.
+func restoredPriorities(rsConfig *RSConfig, original *RSConfig) *RSConfig {
+	priorities := map[int]float64{}
+	for _, member := range original.Members {
+		priorities[member.ID] = member.Priority
+	}
+	newConfig := rsConfig.DeepCopy()
+	for i := range newConfig.Members {
+		if priority, ok := priorities[newConfig.Members[i].ID]; ok {
+			newConfig.Members[i].Priority = priority
+		}
+	}
+	return newConfig
+}
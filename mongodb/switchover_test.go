@@ -0,0 +1,147 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func newSwitchoverReplSet() (*dcs.Cluster, *ReplSetStatus, *RSConfig) {
+	yes := true
+	delay := int64(3600)
+	rsConfig := &RSConfig{
+		Members: ConfigMembers{
+			{ID: 0, Host: "pod-0:27017", Priority: PrimaryPriority},
+			{ID: 1, Host: "pod-1:27017", Priority: SecondaryPriority},
+			{ID: 2, Host: "pod-2:27017", Priority: SecondaryPriority},
+			{ID: 3, Host: "pod-3:27017", Priority: 0, Hidden: &yes},
+			{ID: 4, Host: "pod-4:27017", Priority: 0, SecondaryDelaySecs: &delay},
+			{ID: 5, Host: "pod-5:27017", ArbiterOnly: &yes},
+			{ID: 6, Host: "pod-6:27017", Priority: 0},
+		},
+	}
+	// the members that can not be elected are the most up to date ones
+	rsStatus := &ReplSetStatus{
+		Members: []*Member{
+			mockStatusMember(0, "pod-0:27017", MemberStatePrimary, 1000),
+			mockStatusMember(1, "pod-1:27017", MemberStateSecondary, 990),
+			mockStatusMember(2, "pod-2:27017", MemberStateSecondary, 995),
+			mockStatusMember(3, "pod-3:27017", MemberStateSecondary, 1000),
+			mockStatusMember(4, "pod-4:27017", MemberStateSecondary, 1000),
+			mockStatusMember(5, "pod-5:27017", MemberStateArbiter, 0),
+			mockStatusMember(6, "pod-6:27017", MemberStateSecondary, 1000),
+		},
+	}
+	cluster := &dcs.Cluster{}
+	for _, member := range rsConfig.Members {
+		cluster.Members = append(cluster.Members, dcs.Member{
+			Name:   member.Host[:len("pod-0")],
+			PodIP:  fmt.Sprintf("10.0.0.%d", member.ID+1),
+			DBPort: "27017",
+		})
+	}
+	return cluster, rsStatus, rsConfig
+}
+
+func TestPickSwitchoverCandidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate string
+		expected  string
+		rejection string
+	}{
+		{name: "closest secondary without candidate", expected: "pod-2:27017"},
+		{name: "lagging candidate", candidate: "pod-1", expected: "pod-1:27017"},
+		{name: "hidden candidate", candidate: "pod-3", rejection: "hidden"},
+		{name: "delayed candidate", candidate: "pod-4", rejection: "delayed"},
+		{name: "arbiter candidate", candidate: "pod-5", rejection: "arbiter"},
+		{name: "zero priority candidate", candidate: "pod-6", rejection: "priority is 0"},
+		{name: "primary as candidate", candidate: "pod-0", rejection: "not an electable secondary"},
+	}
+
+	mgr := &Manager{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster, rsStatus, rsConfig := newSwitchoverReplSet()
+			member, err := mgr.pickSwitchoverCandidate(cluster, rsStatus, rsConfig, tt.candidate)
+			if tt.rejection == "" {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expected, member.Name)
+				return
+			}
+
+			var noCandidate *NoCandidateError
+			assert.ErrorAs(t, err, &noCandidate)
+			assert.Equal(t, tt.candidate, noCandidate.Candidate)
+			assert.Len(t, noCandidate.Rejections, 1)
+			assert.Equal(t, tt.rejection, noCandidate.Rejections[0].Reason)
+		})
+	}
+
+	t.Run("arbiter is never picked", func(t *testing.T) {
+		cluster, rsStatus, rsConfig := newSwitchoverReplSet()
+		for _, member := range rsStatus.Members[1:] {
+			if member.State != MemberStateArbiter {
+				member.Health = MemberHealthDown
+			}
+		}
+
+		_, err := mgr.pickSwitchoverCandidate(cluster, rsStatus, rsConfig, "")
+		var noCandidate *NoCandidateError
+		assert.ErrorAs(t, err, &noCandidate)
+		assert.Contains(t, noCandidate.Rejections, CandidateRejection{Member: "pod-5:27017", Reason: "arbiter"})
+	})
+
+	t.Run("no electable secondary", func(t *testing.T) {
+		cluster, rsStatus, rsConfig := newSwitchoverReplSet()
+		rsStatus.Members[1].Health = MemberHealthDown
+		rsStatus.Members[2].State = MemberStateRecovering
+		rsStatus.Members[2].StateStr = MemberStateStrings[MemberStateRecovering]
+
+		_, err := mgr.pickSwitchoverCandidate(cluster, rsStatus, rsConfig, "")
+		var noCandidate *NoCandidateError
+		assert.ErrorAs(t, err, &noCandidate)
+		assert.Len(t, noCandidate.Rejections, 6)
+	})
+}
+
+func TestSwitchoverPriorities(t *testing.T) {
+	_, _, rsConfig := newSwitchoverReplSet()
+
+	raised := raiseCandidatePriority(rsConfig, 2)
+	assert.Equal(t, float64(SecondaryPriority), raised.Members[0].Priority)
+	assert.Equal(t, float64(PrimaryPriority), raised.Members[2].Priority)
+	assert.Equal(t, float64(0), raised.Members[3].Priority)
+	assert.Equal(t, float64(PrimaryPriority), rsConfig.Members[0].Priority)
+
+	// the switchover failed, a member was added meanwhile
+	raised.Members = append(raised.Members, ConfigMember{ID: 7, Host: "pod-7:27017", Priority: SecondaryPriority})
+	restored := restoredPriorities(raised, rsConfig)
+	for i := range rsConfig.Members {
+		assert.Equal(t, rsConfig.Members[i].Priority, restored.Members[i].Priority, rsConfig.Members[i].Host)
+	}
+	assert.Equal(t, float64(SecondaryPriority), restored.Members[7].Priority)
+	assert.Equal(t, float64(PrimaryPriority), raised.Members[2].Priority)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tlsReloader holds the CA and client certificate loaded from files, and
+// loads them again when a file is modified. The TLS configs it builds look
+// them up on every handshake, so long-lived clients pick up rotated
+// certificates with their next connection.
+type tlsReloader struct {
+	caFile   string
+	certFile string
+	keyFile  string
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+	pool     *x509.CertPool
+	cert     *tls.Certificate
+}
+
+var (
+	tlsReloadersMu sync.Mutex
+	tlsReloaders   = map[[3]string]*tlsReloader{}
+)
+
+// getTLSReloader returns the reloader of the config's files, shared by all
+// the clients using them.
+func getTLSReloader(config *Config) *tlsReloader {
+	key := [3]string{config.TLSCAFile, config.TLSCertFile, config.TLSKeyFile}
+	tlsReloadersMu.Lock()
+	defer tlsReloadersMu.Unlock()
+
+	reloader, ok := tlsReloaders[key]
+	if !ok {
+		reloader = &tlsReloader{
+			caFile:   config.TLSCAFile,
+			certFile: config.TLSCertFile,
+			keyFile:  config.TLSKeyFile,
+			modTimes: map[string]time.Time{},
+		}
+		tlsReloaders[key] = reloader
+	}
+	return reloader
+}
+
+// newTLSConfig returns the TLS config of the clients, nil if TLS is not
+// configured.
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	if config.TLSCAFile == "" && config.TLSCertFile == "" {
+		return nil, nil
+	}
+
+	reloader := getTLSReloader(config)
+	if _, _, err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if config.TLSCertFile != "" {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			_, cert, err := reloader.load()
+			return cert, err
+		}
+	}
+	if config.TLSCAFile != "" {
+		// the server certificate is verified against the current CA below,
+		// since the CA of the config can not be replaced once set
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec
+		tlsConfig.VerifyConnection = reloader.verifyConnection
+	}
+	return tlsConfig, nil
+}
+
+func (r *tlsReloader) verifyConnection(cs tls.ConnectionState) error {
+	pool, _, err := r.load()
+	if err != nil {
+		return err
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         pool,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err = cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// load returns the CA pool and client certificate, loading them again if
+// any of the files changed since they were last loaded.
+func (r *tlsReloader) load() (*x509.CertPool, *tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := map[string]time.Time{}
+	for _, file := range []string{r.caFile, r.certFile, r.keyFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "stat tls file")
+		}
+		modTimes[file] = info.ModTime()
+	}
+	if r.isLoaded(modTimes) {
+		return r.pool, r.cert, nil
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		ca, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "read tls ca file")
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, nil, errors.Errorf("no certificate found in %s", r.caFile)
+		}
+	}
+
+	var cert *tls.Certificate
+	if r.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "load tls client certificate")
+		}
+		cert = &pair
+	}
+
+	r.pool, r.cert, r.modTimes = pool, cert, modTimes
+	return r.pool, r.cert, nil
+}
+
+func (r *tlsReloader) isLoaded(modTimes map[string]time.Time) bool {
+	if len(r.modTimes) != len(modTimes) {
+		return false
+	}
+	for file, modTime := range modTimes {
+		if !r.modTimes[file].Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
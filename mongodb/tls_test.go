@@ -0,0 +1,114 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCert writes a self-signed certificate and its key, and sets their
+// modification time so that the reloader sees the change.
+func writeCert(t *testing.T, dir, cn string, modTime time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	assert.Nil(t, os.Chtimes(certFile, modTime, modTime))
+	assert.Nil(t, os.Chtimes(keyFile, modTime, modTime))
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tlsConfig, err := newTLSConfig(&Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, tlsConfig)
+
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writeCert(t, dir, "first", now.Add(-time.Minute))
+	config := &Config{TLSCAFile: certFile, TLSCertFile: certFile, TLSKeyFile: keyFile}
+
+	tlsConfig, err = newTLSConfig(config)
+	assert.Nil(t, err)
+	cert, err := tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.Nil(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "first", leaf.Subject.CommonName)
+	assert.Nil(t, tlsConfig.VerifyConnection(tls.ConnectionState{ServerName: "localhost", PeerCertificates: []*x509.Certificate{leaf}}))
+
+	// the rotated certificate is picked up by the existing config
+	writeCert(t, dir, "second", now)
+	cert, err = tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.Nil(t, err)
+	rotated, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "second", rotated.Subject.CommonName)
+
+	// the old certificate is no longer trusted once the CA is rotated
+	err = tlsConfig.VerifyConnection(tls.ConnectionState{ServerName: "localhost", PeerCertificates: []*x509.Certificate{leaf}})
+	assert.NotNil(t, err)
+}
+
+func TestCredential(t *testing.T) {
+	config := &Config{Username: "root", AuthMechanism: AuthMechanismSCRAMSHA256, AuthSource: "admin"}
+	cred := credential(config, "secret")
+	assert.Equal(t, AuthMechanismSCRAMSHA256, cred.AuthMechanism)
+	assert.Equal(t, "root", cred.Username)
+	assert.Equal(t, "secret", cred.Password)
+
+	config.AuthMechanism = AuthMechanismX509
+	cred = credential(config, "secret")
+	assert.Equal(t, "$external", cred.AuthSource)
+	assert.Empty(t, cred.Username)
+	assert.Empty(t, cred.Password)
+}
@@ -22,6 +22,7 @@ package mongodb
 import (
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -38,42 +39,70 @@ const (
 // ReplsetTags Set tags: https://docs.mongodb.com/manual/tutorial/configure-replica-set-tag-sets/#add-tag-sets-to-a-replica-set
 type ReplsetTags map[string]string
 
+// MarshalBSON encodes nil tags as an empty document, the server stores
+// 'tags: {}' for every member.
+func (t ReplsetTags) MarshalBSON() ([]byte, error) {
+	if t == nil {
+		return bson.Marshal(bson.D{})
+	}
+	return bson.Marshal(map[string]string(t))
+}
+
 // ConfigMember document from 'replSetGetConfig': https://docs.mongodb.com/manual/reference/command/replSetGetConfig/#dbcmd.replSetGetConfig
+// Fields unknown to the plugin are kept in Extra, so that a member read from
+// the server is written back unchanged.
 type ConfigMember struct {
 	ID                 int         `bson:"_id" json:"_id"`
 	Host               string      `bson:"host" json:"host"`
 	ArbiterOnly        *bool       `bson:"arbiterOnly,omitempty" json:"arbiterOnly,omitempty"`
 	BuildIndexes       *bool       `bson:"buildIndexes,omitempty" json:"buildIndexes,omitempty"`
 	Hidden             *bool       `bson:"hidden,omitempty" json:"hidden,omitempty"`
-	Priority           int         `bson:"priority,omitempty" json:"priority,omitempty"`
-	Tags               ReplsetTags `bson:"tags,omitempty" json:"tags,omitempty"`
+	Priority           float64     `bson:"priority" json:"priority"`
+	Tags               ReplsetTags `bson:"tags" json:"tags,omitempty"`
 	SlaveDelay         *int64      `bson:"slaveDelay,omitempty" json:"slaveDelay,omitempty"`
 	SecondaryDelaySecs *int64      `bson:"secondaryDelaySecs,omitempty" json:"secondaryDelaySecs,omitempty"`
 	Votes              *int        `bson:"votes,omitempty" json:"votes,omitempty"`
+	Extra              bson.M      `bson:",inline" json:"-"`
 }
 
 type ConfigMembers []ConfigMember
 
+// RSConfig document from 'replSetGetConfig', fields unknown to the plugin
+// such as 'term' are kept in Extra.
 type RSConfig struct {
 	ID                                 string        `bson:"_id,omitempty" json:"_id,omitempty"`
 	Version                            int           `bson:"version,omitempty" json:"version,omitempty"`
 	Members                            ConfigMembers `bson:"members" json:"members"`
 	Configsvr                          bool          `bson:"configsvr,omitempty" json:"configsvr,omitempty"`
-	ProtocolVersion                    int           `bson:"protocolVersion,omitempty" json:"protocolVersion,omitempty"`
-	Settings                           Settings      `bson:"-" json:"settings,omitempty"`
-	WriteConcernMajorityJournalDefault bool          `bson:"writeConcernMajorityJournalDefault,omitempty" json:"writeConcernMajorityJournalDefault,omitempty"`
+	ProtocolVersion                    int64         `bson:"protocolVersion,omitempty" json:"protocolVersion,omitempty"`
+	Settings                           *Settings     `bson:"settings,omitempty" json:"settings,omitempty"`
+	WriteConcernMajorityJournalDefault *bool         `bson:"writeConcernMajorityJournalDefault,omitempty" json:"writeConcernMajorityJournalDefault,omitempty"`
+	Extra                              bson.M        `bson:",inline" json:"-"`
+}
+
+// DeepCopy returns a copy of the config whose members can be changed without
+// affecting the original.
+func (c *RSConfig) DeepCopy() *RSConfig {
+	newConfig := *c
+	newConfig.Members = make(ConfigMembers, len(c.Members))
+	copy(newConfig.Members, c.Members)
+	return &newConfig
 }
 
 // Settings document from 'replSetGetConfig': https://docs.mongodb.com/manual/reference/command/replSetGetConfig/#dbcmd.replSetGetConfig
+// Pointer fields distinguish an unset value from an explicit zero value such
+// as 'chainingAllowed: false'.
 type Settings struct {
-	ChainingAllowed         bool                   `bson:"chainingAllowed,omitempty" json:"chainingAllowed,omitempty"`
-	HeartbeatIntervalMillis int64                  `bson:"heartbeatIntervalMillis,omitempty" json:"heartbeatIntervalMillis,omitempty"`
-	HeartbeatTimeoutSecs    int                    `bson:"heartbeatTimeoutSecs,omitempty" json:"heartbeatTimeoutSecs,omitempty"`
-	ElectionTimeoutMillis   int64                  `bson:"electionTimeoutMillis,omitempty" json:"electionTimeoutMillis,omitempty"`
-	CatchUpTimeoutMillis    int64                  `bson:"catchUpTimeoutMillis,omitempty" json:"catchUpTimeoutMillis,omitempty"`
-	GetLastErrorModes       map[string]ReplsetTags `bson:"getLastErrorModes,omitempty" json:"getLastErrorModes,omitempty"`
-	GetLastErrorDefaults    WriteConcern           `bson:"getLastErrorDefaults,omitempty" json:"getLastErrorDefaults,omitempty"`
-	ReplicaSetID            primitive.ObjectID     `bson:"replicaSetId,omitempty" json:"replicaSetId,omitempty"`
+	ChainingAllowed            *bool                     `bson:"chainingAllowed,omitempty" json:"chainingAllowed,omitempty"`
+	HeartbeatIntervalMillis    *int                      `bson:"heartbeatIntervalMillis,omitempty" json:"heartbeatIntervalMillis,omitempty"`
+	HeartbeatTimeoutSecs       *int                      `bson:"heartbeatTimeoutSecs,omitempty" json:"heartbeatTimeoutSecs,omitempty"`
+	ElectionTimeoutMillis      *int                      `bson:"electionTimeoutMillis,omitempty" json:"electionTimeoutMillis,omitempty"`
+	CatchUpTimeoutMillis       *int                      `bson:"catchUpTimeoutMillis,omitempty" json:"catchUpTimeoutMillis,omitempty"`
+	CatchUpTakeoverDelayMillis *int                      `bson:"catchUpTakeoverDelayMillis,omitempty" json:"catchUpTakeoverDelayMillis,omitempty"`
+	GetLastErrorModes          map[string]map[string]int `bson:"getLastErrorModes,omitempty" json:"getLastErrorModes,omitempty"`
+	GetLastErrorDefaults       *WriteConcern             `bson:"getLastErrorDefaults,omitempty" json:"getLastErrorDefaults,omitempty"`
+	ReplicaSetID               primitive.ObjectID        `bson:"replicaSetId,omitempty" json:"replicaSetId,omitempty"`
+	Extra                      bson.M                    `bson:",inline" json:"-"`
 }
 
 // ReplSetGetConfig Response document from 'replSetGetConfig': https://docs.mongodb.com/manual/reference/command/replSetGetConfig/#dbcmd.replSetGetConfig
@@ -84,8 +113,20 @@ type ReplSetGetConfig struct {
 
 // BuildInfo contains information about mongod build params
 type BuildInfo struct {
-	Version    string `json:"version" bson:"version"`
-	OKResponse `bson:",inline"`
+	Version      string `json:"version" bson:"version"`
+	VersionArray []int  `json:"versionArray" bson:"versionArray"`
+	OKResponse   `bson:",inline"`
+}
+
+// AtLeast returns true if the server version is major.minor or newer.
+func (b *BuildInfo) AtLeast(major, minor int) bool {
+	if len(b.VersionArray) < 2 {
+		return false
+	}
+	if b.VersionArray[0] != major {
+		return b.VersionArray[0] > major
+	}
+	return b.VersionArray[1] >= minor
 }
 
 // OKResponse is a standard MongoDB response
@@ -98,12 +139,24 @@ type OKResponse struct {
 type WriteConcern struct {
 	WriteConcern interface{} `bson:"w" json:"w"`
 	WriteTimeout int         `bson:"wtimeout" json:"wtimeout"`
-	Journal      bool        `bson:"j,omitempty" json:"j,omitempty"`
+	Journal      *bool       `bson:"j,omitempty" json:"j,omitempty"`
+	Extra        bson.M      `bson:",inline" json:"-"`
 }
 
+// BalancerStatus document from 'balancerStatus': https://www.mongodb.com/docs/manual/reference/command/balancerStatus/
 type BalancerStatus struct {
-	Mode       string `json:"mode"`
-	OKResponse `bson:",inline"`
+	Mode              string `bson:"mode" json:"mode"`
+	InBalancerRound   bool   `bson:"inBalancerRound" json:"inBalancerRound"`
+	NumBalancerRounds int64  `bson:"numBalancerRounds" json:"numBalancerRounds"`
+	OKResponse        `bson:",inline"`
+}
+
+// BalancerWindow is the daily time range the balancer runs in, as HH:MM in
+// the time zone of the config servers. The range wraps around midnight if
+// Stop is before Start.
+type BalancerWindow struct {
+	Start string `bson:"start" json:"start"`
+	Stop  string `bson:"stop" json:"stop"`
 }
 
 type LockResp struct {
@@ -112,6 +165,23 @@ type LockResp struct {
 	OKResponse `bson:",inline"`
 }
 
+// CurrentOpResp is the part of the currentOp response the liveness check
+// needs, FsyncLock is only reported while the server is fsync locked.
+type CurrentOpResp struct {
+	Inprog     []CurrentOp `bson:"inprog" json:"inprog"`
+	FsyncLock  bool        `bson:"fsyncLock,omitempty" json:"fsyncLock,omitempty"`
+	OKResponse `bson:",inline"`
+}
+
+type CurrentOp struct {
+	OpID           interface{} `bson:"opid" json:"opid"`
+	Op             string      `bson:"op" json:"op"`
+	Ns             string      `bson:"ns" json:"ns"`
+	Desc           string      `bson:"desc" json:"desc"`
+	SecsRunning    int64       `bson:"secs_running" json:"secs_running"`
+	WaitingForLock bool        `bson:"waitingForLock" json:"waitingForLock"`
+}
+
 type DBList struct {
 	DBs []struct {
 		Name string `bson:"name" json:"name"`
@@ -121,22 +191,33 @@ type DBList struct {
 
 type ShardList struct {
 	Shards []struct {
-		ID    string `json:"_id" bson:"_id"`
-		Host  string `json:"host" bson:"host"`
-		State int    `json:"state" bson:"state"`
+		ID       string `json:"_id" bson:"_id"`
+		Host     string `json:"host" bson:"host"`
+		State    int    `json:"state" bson:"state"`
+		Draining bool   `json:"draining,omitempty" bson:"draining,omitempty"`
 	} `json:"shards" bson:"shards"`
 	OKResponse `bson:",inline"`
 }
 
+// FCV document from 'getParameter': https://www.mongodb.com/docs/manual/reference/command/setFeatureCompatibilityVersion/
+// TargetVersion is set while the version is changing.
 type FCV struct {
 	FCV struct {
-		Version string `json:"version" bson:"version"`
+		Version       string `json:"version" bson:"version"`
+		TargetVersion string `json:"targetVersion,omitempty" bson:"targetVersion,omitempty"`
 	} `json:"featureCompatibilityVersion" bson:"featureCompatibilityVersion"`
 	OKResponse `bson:",inline"`
 }
 
-const ShardRemoveCompleted string = "completed"
+const (
+	ShardRemoveStarted   string = "started"
+	ShardRemoveOngoing   string = "ongoing"
+	ShardRemoveCompleted string = "completed"
+)
 
+// ShardRemoveResp document from 'removeShard': https://www.mongodb.com/docs/manual/reference/command/removeShard/
+// DBsToMove are the databases whose primary shard is the one being removed,
+// they must be moved before the removal completes.
 type ShardRemoveResp struct {
 	Msg       string `json:"msg" bson:"msg"`
 	State     string `json:"state" bson:"state"`
@@ -144,6 +225,7 @@ type ShardRemoveResp struct {
 		Chunks      int `json:"chunks" bson:"chunks"`
 		JumboChunks int `json:"jumboChunks" bson:"jumboChunks"`
 	} `json:"remaining" bson:"remaining"`
+	DBsToMove  []string `json:"dbsToMove,omitempty" bson:"dbsToMove,omitempty"`
 	OKResponse `bson:",inline"`
 }
 
@@ -156,6 +238,51 @@ type IsMasterResp struct {
 	OKResponse `bson:",inline"`
 }
 
+// OplogWindow is the range of operations kept in the oplog, a member whose
+// optime is older than First can not catch up from it.
+type OplogWindow struct {
+	First primitive.Timestamp
+	Last  primitive.Timestamp
+}
+
+// Seconds returns how many seconds of operations the oplog holds.
+func (w *OplogWindow) Seconds() int64 {
+	return int64(w.Last.T) - int64(w.First.T)
+}
+
+// ElectionMetrics is the 'electionMetrics' section of 'serverStatus', the
+// elections the member called by reason: https://www.mongodb.com/docs/manual/reference/command/serverStatus/#electionmetrics
+type ElectionMetrics struct {
+	StepUpCmd        ElectionCounter `bson:"stepUpCmd" json:"stepUpCmd"`
+	PriorityTakeover ElectionCounter `bson:"priorityTakeover" json:"priorityTakeover"`
+	CatchUpTakeover  ElectionCounter `bson:"catchUpTakeover" json:"catchUpTakeover"`
+	ElectionTimeout  ElectionCounter `bson:"electionTimeout" json:"electionTimeout"`
+	FreezeTimeout    ElectionCounter `bson:"freezeTimeout" json:"freezeTimeout"`
+}
+
+type ElectionCounter struct {
+	Called     int64 `bson:"called" json:"called"`
+	Successful int64 `bson:"successful" json:"successful"`
+}
+
+// CmdLineOpts is the part of the 'getCmdLineOpts' response the plugin needs:
+// https://www.mongodb.com/docs/manual/reference/command/getCmdLineOpts/
+type CmdLineOpts struct {
+	Parsed struct {
+		Sharding struct {
+			ClusterRole string `bson:"clusterRole,omitempty" json:"clusterRole,omitempty"`
+		} `bson:"sharding" json:"sharding"`
+	} `bson:"parsed" json:"parsed"`
+	OKResponse `bson:",inline"`
+}
+
+// DefaultRWConcernResp document from 'getDefaultRWConcern': https://www.mongodb.com/docs/manual/reference/command/getDefaultRWConcern/
+type DefaultRWConcernResp struct {
+	DefaultWriteConcern       *WriteConcern `bson:"defaultWriteConcern,omitempty" json:"defaultWriteConcern,omitempty"`
+	DefaultWriteConcernSource string        `bson:"defaultWriteConcernSource,omitempty" json:"defaultWriteConcernSource,omitempty"`
+	OKResponse                `bson:",inline"`
+}
+
 type ReplSetStatus struct {
 	Set                     string         `bson:"set" json:"set"`
 	Date                    time.Time      `bson:"date" json:"date"`
@@ -177,6 +304,7 @@ type Member struct {
 	Optime            *Optime             `bson:"optime" json:"optime"`
 	OptimeDate        time.Time           `bson:"optimeDate" json:"optimeDate"`
 	ConfigVersion     int                 `bson:"configVersion" json:"configVersion"`
+	ConfigTerm        int64               `bson:"configTerm,omitempty" json:"configTerm,omitempty"`
 	ElectionTime      primitive.Timestamp `bson:"electionTime,omitempty" json:"electionTime,omitempty"`
 	ElectionDate      time.Time           `bson:"electionDate,omitempty" json:"electionDate,omitempty"`
 	InfoMessage       string              `bson:"infoMessage,omitempty" json:"infoMessage,omitempty"`
@@ -269,9 +397,11 @@ type RolePrivilege struct {
 type Role struct {
 	Role       string                   `bson:"role" json:"role"`
 	DB         string                   `bson:"db" json:"db"`
-	IsBuiltin  string                   `bson:"isBuiltin" json:"isBuiltin"`
+	IsBuiltin  bool                     `bson:"isBuiltin" json:"isBuiltin"`
 	Roles      []map[string]interface{} `bson:"roles" json:"roles"`
 	Privileges []RolePrivilege          `bson:"privileges" json:"privileges"`
+
+	InheritedPrivileges []RolePrivilege `bson:"inheritedPrivileges,omitempty" json:"inheritedPrivileges,omitempty"`
 }
 
 type RoleInfo struct {
@@ -284,6 +414,8 @@ type User struct {
 	User  string                   `bson:"user" json:"user"`
 	DB    string                   `bson:"db" json:"db"`
 	Roles []map[string]interface{} `bson:"roles" json:"roles"`
+
+	Mechanisms []string `bson:"mechanisms,omitempty" json:"mechanisms,omitempty"`
 }
 
 type UsersInfo struct {
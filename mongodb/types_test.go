@@ -0,0 +1,182 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// rsConfigFixture is a replSetGetConfig reply from a MongoDB 6.0 replica set.
+const rsConfigFixture = `{
+	"_id": "mongo-mongodb",
+	"version": {"$numberInt": "5"},
+	"term": {"$numberInt": "3"},
+	"members": [
+		{
+			"_id": {"$numberInt": "0"},
+			"host": "mongo-mongodb-0.mongo-mongodb-headless:27017",
+			"arbiterOnly": false,
+			"buildIndexes": true,
+			"hidden": false,
+			"priority": {"$numberDouble": "2.0"},
+			"tags": {"zone": "a"},
+			"secondaryDelaySecs": {"$numberLong": "0"},
+			"votes": {"$numberInt": "1"}
+		},
+		{
+			"_id": {"$numberInt": "1"},
+			"host": "mongo-mongodb-1.mongo-mongodb-headless:27017",
+			"arbiterOnly": false,
+			"buildIndexes": true,
+			"hidden": false,
+			"priority": {"$numberDouble": "1.0"},
+			"tags": {"zone": "b"},
+			"secondaryDelaySecs": {"$numberLong": "0"},
+			"votes": {"$numberInt": "1"}
+		},
+		{
+			"_id": {"$numberInt": "2"},
+			"host": "mongo-mongodb-2.mongo-mongodb-headless:27017",
+			"arbiterOnly": false,
+			"buildIndexes": true,
+			"hidden": true,
+			"priority": {"$numberDouble": "0.0"},
+			"tags": {},
+			"secondaryDelaySecs": {"$numberLong": "3600"},
+			"votes": {"$numberInt": "1"}
+		}
+	],
+	"protocolVersion": {"$numberLong": "1"},
+	"writeConcernMajorityJournalDefault": false,
+	"settings": {
+		"chainingAllowed": false,
+		"heartbeatIntervalMillis": {"$numberInt": "2000"},
+		"heartbeatTimeoutSecs": {"$numberInt": "10"},
+		"electionTimeoutMillis": {"$numberInt": "5000"},
+		"catchUpTimeoutMillis": {"$numberInt": "-1"},
+		"catchUpTakeoverDelayMillis": {"$numberInt": "30000"},
+		"getLastErrorModes": {"multiZone": {"zone": {"$numberInt": "2"}}},
+		"getLastErrorDefaults": {"w": {"$numberInt": "1"}, "wtimeout": {"$numberInt": "0"}},
+		"replicaSetId": {"$oid": "6527a1e2c3d4e5f60718293a"}
+	}
+}`
+
+func decodeRSConfigFixture(t *testing.T) *RSConfig {
+	raw := bson.M{}
+	require.NoError(t, bson.UnmarshalExtJSON([]byte(rsConfigFixture), true, &raw))
+	data, err := bson.Marshal(raw)
+	require.NoError(t, err)
+
+	rsConfig := &RSConfig{}
+	require.NoError(t, bson.Unmarshal(data, rsConfig))
+	return rsConfig
+}
+
+// flattenDoc returns the document as a map from dotted path to canonical
+// extended JSON value, so that two documents can be compared field by field,
+// including the value types, regardless of the field order.
+func flattenDoc(t *testing.T, doc interface{}) map[string]interface{} {
+	data, err := bson.MarshalExtJSON(doc, true, false)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+
+	flat := map[string]interface{}{}
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				flat[prefix] = "{}"
+			}
+			for k, e := range v {
+				walk(prefix+"."+k, e)
+			}
+		case []interface{}:
+			for i, e := range v {
+				walk(prefix+"."+strconv.Itoa(i), e)
+			}
+		default:
+			flat[prefix] = v
+		}
+	}
+	walk("", m)
+	return flat
+}
+
+func fixtureDoc(t *testing.T) bson.M {
+	doc := bson.M{}
+	require.NoError(t, bson.UnmarshalExtJSON([]byte(rsConfigFixture), true, &doc))
+	return doc
+}
+
+func TestRSConfigRoundTrip(t *testing.T) {
+	rsConfig := decodeRSConfigFixture(t)
+
+	assert.Equal(t, "mongo-mongodb", rsConfig.ID)
+	assert.Equal(t, 5, rsConfig.Version)
+	require.NotNil(t, rsConfig.Settings)
+	require.NotNil(t, rsConfig.Settings.ChainingAllowed)
+	assert.False(t, *rsConfig.Settings.ChainingAllowed)
+	require.NotNil(t, rsConfig.Settings.ElectionTimeoutMillis)
+	assert.Equal(t, 5000, *rsConfig.Settings.ElectionTimeoutMillis)
+	assert.Equal(t, "6527a1e2c3d4e5f60718293a", rsConfig.Settings.ReplicaSetID.Hex())
+	assert.Equal(t, float64(0), rsConfig.Members[2].Priority)
+	assert.Contains(t, rsConfig.Extra, "term")
+
+	assert.Equal(t, flattenDoc(t, fixtureDoc(t)), flattenDoc(t, rsConfig))
+}
+
+func TestRSConfigReconfigChangesOnlyIntendedFields(t *testing.T) {
+	rsConfig := decodeRSConfigFixture(t)
+	before := flattenDoc(t, rsConfig)
+
+	newConfig := rsConfig.DeepCopy()
+	newConfig.Members[0].Priority = SecondaryPriority
+	newConfig.Members[1].Priority = PrimaryPriority
+	newConfig.Version++
+	after := flattenDoc(t, newConfig)
+
+	changed := map[string]bool{}
+	for k, v := range before {
+		if after[k] != v {
+			changed[k] = true
+		}
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			changed[k] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		".version.$numberInt":               true,
+		".members.0.priority.$numberDouble": true,
+		".members.1.priority.$numberDouble": true,
+	}, changed)
+
+	// the original config is left untouched
+	assert.Equal(t, before, flattenDoc(t, rsConfig))
+}
@@ -71,6 +71,45 @@ func GetUser(ctx context.Context, client *mongo.Client, userName string) (*User,
 	return &resp.Users[0], nil
 }
 
+// ListUsers returns the users of all databases.
+func ListUsers(ctx context.Context, client *mongo.Client) ([]User, error) {
+	resp := UsersInfo{}
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "usersInfo", Value: bson.M{"forAllDBs": true}}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "run command")
+	}
+
+	err := res.Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode response")
+	}
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+	return resp.Users, nil
+}
+
+// GetDBUser returns the user of the database.
+func GetDBUser(ctx context.Context, client *mongo.Client, userName, db string) (*User, error) {
+	resp := UsersInfo{}
+	res := client.Database(db).RunCommand(ctx, bson.D{{Key: "usersInfo", Value: bson.M{"user": userName, "db": db}}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "run command")
+	}
+
+	err := res.Decode(&resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode response")
+	}
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+	if len(resp.Users) == 0 {
+		return nil, nil
+	}
+	return &resp.Users[0], nil
+}
+
 func UpdateUserRoles(ctx context.Context, client *mongo.Client, userName string, roles []map[string]interface{}) error {
 	return client.Database("admin").RunCommand(ctx, bson.D{{Key: "updateUser", Value: userName}, {Key: "roles", Value: roles}}).Err()
 }
@@ -22,6 +22,10 @@ package mongodb
 import (
 	"context"
 
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
 	"github.com/apecloud/mongodb_plugin/util"
 )
 
@@ -29,3 +33,23 @@ func GetVersion() (string, error) {
 	command := []string{"mongod", "--version"}
 	return util.ExecCommand(context.Background(), command)
 }
+
+// GetBuildInfo returns the version of the server the client is connected to.
+func GetBuildInfo(ctx context.Context, client *mongo.Client) (*BuildInfo, error) {
+	resp := &BuildInfo{}
+
+	res := client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}})
+	if res.Err() != nil {
+		return nil, errors.Wrap(res.Err(), "buildInfo")
+	}
+
+	if err := res.Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode buildInfo response")
+	}
+
+	if resp.OK != 1 {
+		return nil, errors.Errorf("mongo says: %s", resp.Errmsg)
+	}
+
+	return resp, nil
+}
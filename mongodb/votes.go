@@ -0,0 +1,131 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"github.com/pkg/errors"
+)
+
+// IsVoter returns true if the member votes in elections, members vote unless
+// votes is explicitly set to 0.
+func (m *ConfigMember) IsVoter() bool {
+	return m.Votes == nil || *m.Votes > 0
+}
+
+// VoterCount returns the number of voting members.
+func (c *RSConfig) VoterCount() int {
+	count := 0
+	for i := range c.Members {
+		if c.Members[i].IsVoter() {
+			count++
+		}
+	}
+	return count
+}
+
+// setJoinVotes makes the new member a non-voter with priority 0 if the replica
+// set already has MaxVotingMembers voters. It fails if the replica set already
+// has MaxMembers members, or if an arbiter, which must vote, joins a set with
+// MaxVotingMembers voters.
+func setJoinVotes(rsConfig *RSConfig, member *ConfigMember) error {
+	if len(rsConfig.Members) >= MaxMembers {
+		return errors.Errorf("replica set %s already has %d members, the maximum MongoDB allows", rsConfig.ID, MaxMembers)
+	}
+	if rsConfig.VoterCount() >= MaxVotingMembers {
+		if member.ArbiterOnly != nil && *member.ArbiterOnly {
+			return errors.Errorf("replica set %s already has %d voting members, an arbiter must vote", rsConfig.ID, MaxVotingMembers)
+		}
+		votes := 0
+		member.Votes = &votes
+		member.Priority = 0
+	}
+	return nil
+}
+
+// promoteNonVoter gives a vote and, unless it is hidden or delayed, the
+// secondary priority back to the non-voter with the lowest ID while the
+// replica set has fewer than MaxVotingMembers voters, and returns it.
+func promoteNonVoter(rsConfig *RSConfig) *ConfigMember {
+	if rsConfig.VoterCount() >= MaxVotingMembers {
+		return nil
+	}
+
+	var promoted *ConfigMember
+	for i := range rsConfig.Members {
+		m := &rsConfig.Members[i]
+		if m.IsVoter() {
+			continue
+		}
+		if promoted == nil || m.ID < promoted.ID {
+			promoted = m
+		}
+	}
+	if promoted == nil {
+		return nil
+	}
+
+	votes := DefaultVotes
+	promoted.Votes = &votes
+	if (promoted.Hidden == nil || !*promoted.Hidden) && promoted.GetDelaySecs() == 0 {
+		promoted.Priority = SecondaryPriority
+	}
+	return promoted
+}
+
+// promoteNonVoters promotes non-voters with promoteNonVoter until the replica
+// set has MaxVotingMembers voters or no non-voter is left, and returns them.
+func promoteNonVoters(rsConfig *RSConfig) []*ConfigMember {
+	var promoted []*ConfigMember
+	for {
+		member := promoteNonVoter(rsConfig)
+		if member == nil {
+			return promoted
+		}
+		promoted = append(promoted, member)
+	}
+}
+
+// demoteVoter takes the vote and the priority of the voter with the highest
+// ID that is neither the primary nor an arbiter if the replica set has an
+// even number of voters, and returns it.
+func demoteVoter(rsConfig *RSConfig, primary string) *ConfigMember {
+	if rsConfig.VoterCount()%2 == 1 {
+		return nil
+	}
+
+	var demoted *ConfigMember
+	for i := range rsConfig.Members {
+		m := &rsConfig.Members[i]
+		if !m.IsVoter() || m.Host == primary || (m.ArbiterOnly != nil && *m.ArbiterOnly) {
+			continue
+		}
+		if demoted == nil || m.ID > demoted.ID {
+			demoted = m
+		}
+	}
+	if demoted == nil {
+		return nil
+	}
+
+	votes := 0
+	demoted.Votes = &votes
+	demoted.Priority = 0
+	return demoted
+}
@@ -0,0 +1,200 @@
+/*
+Copyright (C) 2022-2024 ApeCloud Co., Ltd
+
+This file is part of KubeBlocks project
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package mongodb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apecloud/mongodb_plugin/dcs"
+)
+
+func mockRSConfig(voters, nonVoters int) *RSConfig {
+	rsConfig := &RSConfig{ID: "rs0"}
+	for i := 0; i < voters+nonVoters; i++ {
+		member := ConfigMember{ID: i, Host: fmt.Sprintf("pod-%d:27017", i), Priority: SecondaryPriority}
+		if i >= voters {
+			votes := 0
+			member.Votes = &votes
+			member.Priority = 0
+		}
+		rsConfig.Members = append(rsConfig.Members, member)
+	}
+	return rsConfig
+}
+
+func TestSetJoinVotes(t *testing.T) {
+	t.Run("joins as voter", func(t *testing.T) {
+		member := &ConfigMember{ID: 6, Priority: SecondaryPriority}
+		assert.Nil(t, setJoinVotes(mockRSConfig(6, 0), member))
+		assert.True(t, member.IsVoter())
+		assert.Equal(t, float64(SecondaryPriority), member.Priority)
+	})
+
+	t.Run("joins as non-voter beyond seven voters", func(t *testing.T) {
+		member := &ConfigMember{ID: 7, Priority: SecondaryPriority}
+		assert.Nil(t, setJoinVotes(mockRSConfig(7, 0), member))
+		assert.False(t, member.IsVoter())
+		assert.Equal(t, float64(0), member.Priority)
+	})
+
+	t.Run("arbiter beyond seven voters", func(t *testing.T) {
+		arbiter := true
+		member := &ConfigMember{ID: 7, ArbiterOnly: &arbiter}
+		assert.EqualError(t, setJoinVotes(mockRSConfig(7, 0), member),
+			"replica set rs0 already has 7 voting members, an arbiter must vote")
+	})
+
+	t.Run("refuses more than fifty members", func(t *testing.T) {
+		member := &ConfigMember{ID: 50, Priority: SecondaryPriority}
+		assert.EqualError(t, setJoinVotes(mockRSConfig(7, 43), member),
+			"replica set rs0 already has 50 members, the maximum MongoDB allows")
+	})
+}
+
+func TestJoinArbiter(t *testing.T) {
+	t.Run("joins with arbiterOnly and priority 0", func(t *testing.T) {
+		rsConfig := mockRSConfig(2, 0)
+		member := newJoinConfigMember(rsConfig, "pod-2:27017", true)
+		assert.Nil(t, setJoinVotes(rsConfig, &member))
+		assert.Equal(t, 2, member.ID)
+		assert.True(t, *member.ArbiterOnly)
+		assert.Equal(t, float64(0), member.Priority)
+		assert.True(t, member.IsVoter())
+		assert.Equal(t, "arbiter", member.ineligibleReason())
+	})
+
+	t.Run("secondary joins electable", func(t *testing.T) {
+		rsConfig := mockRSConfig(2, 0)
+		member := newJoinConfigMember(rsConfig, "pod-2:27017", false)
+		assert.Nil(t, member.ArbiterOnly)
+		assert.Equal(t, float64(SecondaryPriority), member.Priority)
+		assert.Equal(t, "", member.ineligibleReason())
+	})
+
+	t.Run("initiates with arbiterOnly and priority 0", func(t *testing.T) {
+		mgr := &Manager{CurrentMemberName: "pod-0", ClusterCompName: "rs"}
+		cluster := &dcs.Cluster{Members: []dcs.Member{
+			{Name: "pod-0", PodIP: "10.0.0.1", DBPort: "27017", UseIP: true},
+			{Name: "pod-1", PodIP: "10.0.0.2", DBPort: "27017", UseIP: true},
+			{Name: "pod-2", PodIP: "10.0.0.3", DBPort: "27017", UseIP: true, IsArbiter: true},
+		}}
+		rsConfig, err := mgr.newInitiateConfig(cluster, ComponentRoleReplSet)
+		assert.Nil(t, err)
+		assert.True(t, *rsConfig.Members[2].ArbiterOnly)
+		assert.Equal(t, float64(0), rsConfig.Members[2].Priority)
+		assert.Nil(t, rsConfig.Members[1].ArbiterOnly)
+	})
+}
+
+func TestPromoteNonVoter(t *testing.T) {
+	t.Run("promotes the lowest non-voter", func(t *testing.T) {
+		rsConfig := mockRSConfig(7, 3)
+		rsConfig.Members = append(rsConfig.Members[:2], rsConfig.Members[3:]...)
+
+		promoted := promoteNonVoter(rsConfig)
+		assert.NotNil(t, promoted)
+		assert.Equal(t, 7, promoted.ID)
+		assert.True(t, promoted.IsVoter())
+		assert.Equal(t, float64(SecondaryPriority), promoted.Priority)
+		assert.Equal(t, 7, rsConfig.VoterCount())
+		assert.Nil(t, promoteNonVoter(rsConfig))
+	})
+
+	t.Run("keeps hidden members at priority 0", func(t *testing.T) {
+		hidden := true
+		rsConfig := mockRSConfig(6, 1)
+		rsConfig.Members[6].Hidden = &hidden
+
+		promoted := promoteNonVoter(rsConfig)
+		assert.NotNil(t, promoted)
+		assert.Equal(t, float64(0), promoted.Priority)
+	})
+
+	t.Run("keeps seven voters when a voter leaves", func(t *testing.T) {
+		rsConfig := mockRSConfig(7, 2)
+		assert.Nil(t, promoteNonVoter(rsConfig))
+
+		rsConfig.Members = rsConfig.Members[1:]
+		assert.Equal(t, 6, rsConfig.VoterCount())
+		promoted := promoteNonVoter(rsConfig)
+		assert.NotNil(t, promoted)
+		assert.Equal(t, 7, promoted.ID)
+		assert.Equal(t, 7, rsConfig.VoterCount())
+		assert.Nil(t, promoteNonVoter(rsConfig))
+	})
+
+	t.Run("eighth member joins as non-voter", func(t *testing.T) {
+		rsConfig := mockRSConfig(7, 0)
+		member := newJoinConfigMember(rsConfig, "pod-7:27017", false)
+		assert.Nil(t, setJoinVotes(rsConfig, &member))
+		rsConfig.Members = append(rsConfig.Members, member)
+		assert.Equal(t, 7, rsConfig.VoterCount())
+		assert.Nil(t, promoteNonVoter(rsConfig))
+	})
+
+	t.Run("nothing to promote", func(t *testing.T) {
+		assert.Nil(t, promoteNonVoter(mockRSConfig(7, 2)))
+		assert.Nil(t, promoteNonVoter(mockRSConfig(4, 0)))
+	})
+}
+
+func TestPromoteNonVoters(t *testing.T) {
+	rsConfig := mockRSConfig(3, 5)
+	promoted := promoteNonVoters(rsConfig)
+	assert.Len(t, promoted, 4)
+	assert.Equal(t, 3, promoted[0].ID)
+	assert.Equal(t, 6, promoted[3].ID)
+	assert.Equal(t, MaxVotingMembers, rsConfig.VoterCount())
+	assert.Empty(t, promoteNonVoters(rsConfig))
+}
+
+func TestDemoteVoter(t *testing.T) {
+	t.Run("demotes the highest voter when five voters become four", func(t *testing.T) {
+		rsConfig := mockRSConfig(5, 0)
+		rsConfig.Members = append(rsConfig.Members[:2], rsConfig.Members[3:]...)
+		assert.Nil(t, promoteNonVoter(rsConfig))
+
+		demoted := demoteVoter(rsConfig, "pod-0:27017")
+		assert.NotNil(t, demoted)
+		assert.Equal(t, 4, demoted.ID)
+		assert.False(t, demoted.IsVoter())
+		assert.Equal(t, float64(0), demoted.Priority)
+		assert.Equal(t, 3, rsConfig.VoterCount())
+		assert.Nil(t, demoteVoter(rsConfig, "pod-0:27017"))
+	})
+
+	t.Run("skips the primary and arbiters", func(t *testing.T) {
+		arbiter := true
+		rsConfig := mockRSConfig(4, 0)
+		rsConfig.Members[3].ArbiterOnly = &arbiter
+
+		demoted := demoteVoter(rsConfig, "pod-2:27017")
+		assert.NotNil(t, demoted)
+		assert.Equal(t, 1, demoted.ID)
+	})
+
+	t.Run("nothing to demote", func(t *testing.T) {
+		assert.Nil(t, demoteVoter(mockRSConfig(3, 1), ""))
+		assert.Nil(t, demoteVoter(mockRSConfig(7, 0), ""))
+	})
+}